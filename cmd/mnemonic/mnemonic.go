@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/keng42/go/cnigma/mnemonic"
+)
+
+const usage = `usage:
+  mnemonic encode [key]      print the words of a base64 key, read from stdin if omitted
+  mnemonic decode [words...] print the base64 key of the words, read from stdin if omitted`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	input := strings.Join(os.Args[2:], " ")
+	if input == "" {
+		buf, err := io.ReadAll(bufio.NewReader(os.Stdin))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		input = strings.TrimSpace(string(buf))
+	}
+
+	var out string
+	var err error
+	switch os.Args[1] {
+	case "encode":
+		out, err = mnemonic.FromKey(input)
+		if err == nil {
+			out = numbered(out)
+		}
+	case "decode":
+		out, err = mnemonic.ToKey(stripNumbers(input))
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(out)
+}
+
+// numbered prints the words in numbered rows of 4 words, easier to write down and read out
+func numbered(m string) string {
+	var rows []string
+	var row string
+	for i, w := range strings.Fields(m) {
+		row += fmt.Sprintf("%2d. %-10s", i+1, w)
+		if i%4 == 3 {
+			rows = append(rows, strings.TrimRight(row, " "))
+			row = ""
+		}
+	}
+	if row != "" {
+		rows = append(rows, strings.TrimRight(row, " "))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// stripNumbers removes the "1." numbers printed by encode
+func stripNumbers(s string) string {
+	var words []string
+	for _, f := range strings.Fields(s) {
+		if strings.HasSuffix(f, ".") && strings.Trim(f, "0123456789.") == "" {
+			continue
+		}
+		words = append(words, f)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/keng42/go/cnigma/aes"
+	"github.com/keng42/go/random"
+)
+
+// output holds the flags shared by the key subcommands
+type output struct {
+	path  string
+	perm  string
+	force bool
+}
+
+func (o *output) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.path, "out", "", "output file, stdout if omitted")
+	fs.StringVar(&o.perm, "perm", "0600", "permissions of the output file")
+	fs.BoolVar(&o.force, "force", false, "overwrite an existing output file")
+}
+
+// write writes data to the output file with its permissions, or to stdout
+func (o *output) write(path string, data []byte, perm os.FileMode) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	p, err := o.prepare(path, data, perm)
+	if err != nil {
+		return err
+	}
+	return p.commit()
+}
+
+// pending is an output file written to a temporary file next to it until commit moves it into place,
+// so a failed write never leaves a truncated file and -force replaces symlinks instead of their targets.
+type pending struct {
+	tmp     string
+	path    string
+	existed bool
+}
+
+// prepare writes data to a temporary file in the directory of path
+func (o *output) prepare(path string, data []byte, perm os.FileMode) (*pending, error) {
+	_, err := os.Lstat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	existed := err == nil
+	if existed && !o.force {
+		return nil, fmt.Errorf("%s already exists, use -force to overwrite it", path)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	p := &pending{tmp: f.Name(), path: path, existed: existed}
+	// CreateTemp always uses 0600
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		p.discard()
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		p.discard()
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		p.discard()
+		return nil, err
+	}
+	return p, nil
+}
+
+// commit moves the temporary file into place.
+// A path that didn't exist is linked rather than renamed, so a file created meanwhile isn't overwritten.
+func (p *pending) commit() error {
+	var err error
+	if p.existed {
+		err = os.Rename(p.tmp, p.path)
+	} else {
+		err = os.Link(p.tmp, p.path)
+	}
+	p.discard()
+	return err
+}
+
+// discard removes the temporary file if it's still there
+func (p *pending) discard() {
+	os.Remove(p.tmp)
+}
+
+func (o *output) mode() (os.FileMode, error) {
+	perm, err := strconv.ParseUint(o.perm, 8, 32)
+	if err != nil || perm > 0777 {
+		return 0, fmt.Errorf("invalid permissions %q", o.perm)
+	}
+	return os.FileMode(perm), nil
+}
+
+// aesKey prints a key in the format of aes.NewAES
+func aesKey(args []string) error {
+	fs := flag.NewFlagSet("aes", flag.ExitOnError)
+	bits := fs.Int("bits", 256, "key size, 128, 192 or 256")
+	var o output
+	o.register(fs)
+	fs.Parse(args)
+
+	if *bits != 128 && *bits != 192 && *bits != 256 {
+		return fmt.Errorf("aes key size must be 128, 192 or 256 bits")
+	}
+	perm, err := o.mode()
+	if err != nil {
+		return err
+	}
+	key, err := aes.NewKey(*bits)
+	if err != nil {
+		return err
+	}
+	return o.write(o.path, []byte(key+"\n"), perm)
+}
+
+// rsaKey writes a PKCS8 private key and a PKIX public key to <out> and <out>.pub
+func rsaKey(args []string) error {
+	fs := flag.NewFlagSet("rsa", flag.ExitOnError)
+	bits := fs.Int("bits", 2048, "key size, at least 2048")
+	var o output
+	o.register(fs)
+	fs.Parse(args)
+
+	if *bits < 2048 {
+		return fmt.Errorf("rsa key size must be at least 2048 bits")
+	}
+	perm, err := o.mode()
+	if err != nil {
+		return err
+	}
+	priv, err := rsa.GenerateKey(rand.Reader, *bits)
+	if err != nil {
+		return err
+	}
+	return writeKeyPair(&o, perm, priv, &priv.PublicKey)
+}
+
+// ed25519Key writes a PKCS8 private key and a PKIX public key to <out> and <out>.pub
+func ed25519Key(args []string) error {
+	fs := flag.NewFlagSet("ed25519", flag.ExitOnError)
+	var o output
+	o.register(fs)
+	fs.Parse(args)
+
+	perm, err := o.mode()
+	if err != nil {
+		return err
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	return writeKeyPair(&o, perm, priv, pub)
+}
+
+func writeKeyPair(o *output, perm os.FileMode, priv, pub interface{}) error {
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	if o.path == "" {
+		return o.write("", append(privPEM, pubPEM...), perm)
+	}
+	// both files are written before either is moved into place,
+	// so an existing public key or a failed write doesn't leave a lone private key behind
+	privFile, err := o.prepare(o.path, privPEM, perm)
+	if err != nil {
+		return err
+	}
+	pubFile, err := o.prepare(o.path+".pub", pubPEM, 0644)
+	if err != nil {
+		privFile.discard()
+		return err
+	}
+	if err := privFile.commit(); err != nil {
+		pubFile.discard()
+		return err
+	}
+	if err := pubFile.commit(); err != nil {
+		// only a private key created by this call is removed, never one that -force replaced
+		if !privFile.existed {
+			os.Remove(o.path)
+		}
+		return err
+	}
+	return nil
+}
+
+// secret prints a random HMAC or JWT signing secret
+func secret(args []string) error {
+	fs := flag.NewFlagSet("hmac", flag.ExitOnError)
+	size := fs.Int("bytes", 32, "secret size in bytes, at least 32")
+	encoding := fs.String("encoding", "base64url", "base64url, base64 or hex")
+	var o output
+	o.register(fs)
+	fs.Parse(args)
+
+	if *size < 32 {
+		return fmt.Errorf("secret size must be at least 32 bytes")
+	}
+	perm, err := o.mode()
+	if err != nil {
+		return err
+	}
+	buf, err := random.Bytes(*size)
+	if err != nil {
+		return err
+	}
+
+	var s string
+	switch *encoding {
+	case "base64url":
+		s = base64.RawURLEncoding.EncodeToString(buf)
+	case "base64":
+		s = base64.StdEncoding.EncodeToString(buf)
+	case "hex":
+		s = hex.EncodeToString(buf)
+	default:
+		return fmt.Errorf("unsupported encoding %q", *encoding)
+	}
+	return o.write(o.path, []byte(s+"\n"), perm)
+}
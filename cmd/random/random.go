@@ -1,7 +1,156 @@
 package main
 
-import "github.com/keng42/go/random"
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/keng42/go/random"
+)
+
+const usage = `usage:
+  random                 print random hex, base64url and ascii85 texts
+  random -f base64url -n 43 [-c count] [-json] [-no-newline] [-copy-safe]
+                         print random texts, see random -h
+  random passphrase      print a diceware-style passphrase, see random passphrase -h
+  random uuid            print uuids, -version 4 or 7
+  random ulid            print ulids, -monotonic keeps them increasing
+  random nanoid          print nanoids, -alphabet and -size customize them
+  random aes             print an aes key for aes.NewAES, -bits 128, 192 or 256
+  random rsa             print a rsa key pair as PEM, -bits 2048 or more
+  random hmac|jwt        print a HMAC or JWT signing secret, -bytes and -encoding customize it
+  random ed25519         print an ed25519 key pair as PEM
+                         key commands write to -out (public keys to <out>.pub) with -perm 0600`
 
 func main() {
-	random.NewTexts()
+	var err error
+	switch {
+	case len(os.Args) < 2:
+		err = random.NewTexts()
+	case strings.HasPrefix(os.Args[1], "-"):
+		err = text(os.Args[1:])
+	case os.Args[1] == "passphrase":
+		err = passphrase(os.Args[2:])
+	case os.Args[1] == "uuid":
+		err = uuid(os.Args[2:])
+	case os.Args[1] == "ulid":
+		err = ulid(os.Args[2:])
+	case os.Args[1] == "nanoid":
+		err = nanoid(os.Args[2:])
+	case os.Args[1] == "aes":
+		err = aesKey(os.Args[2:])
+	case os.Args[1] == "rsa":
+		err = rsaKey(os.Args[2:])
+	case os.Args[1] == "hmac", os.Args[1] == "jwt":
+		err = secret(os.Args[2:])
+	case os.Args[1] == "ed25519":
+		err = ed25519Key(os.Args[2:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func passphrase(args []string) error {
+	fs := flag.NewFlagSet("passphrase", flag.ExitOnError)
+	words := fs.Int("words", 6, "number of words")
+	separator := fs.String("separator", "-", "separator between words, may be empty")
+	capitalize := fs.Bool("capitalize", false, "capitalize every word")
+	digits := fs.Int("digits", 0, "number of words that get a random digit appended")
+	wordlist := fs.String("wordlist", "", "custom wordlist file, one word per line, defaults to the EFF large wordlist")
+	fs.Parse(args)
+
+	opts := random.PassphraseOptions{
+		Words:       *words,
+		Separator:   *separator,
+		NoSeparator: *separator == "",
+		Capitalize:  *capitalize,
+		Digits:      *digits,
+	}
+	if *wordlist != "" {
+		f, err := os.Open(*wordlist)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		opts.Wordlist, err = random.LoadWordlist(f)
+		if err != nil {
+			return err
+		}
+	}
+
+	s, err := random.Passphrase(opts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(s.Text)
+	fmt.Fprintf(os.Stderr, "entropy: %.1f bits\n", s.Entropy)
+	return nil
+}
+
+func uuid(args []string) error {
+	fs := flag.NewFlagSet("uuid", flag.ExitOnError)
+	version := fs.Int("version", 4, "uuid version, 4 or 7")
+	count := fs.Int("count", 1, "number of uuids")
+	fs.Parse(args)
+
+	for i := 0; i < *count; i++ {
+		var u random.UUID
+		var err error
+		switch *version {
+		case 4:
+			u, err = random.UUIDv4()
+		case 7:
+			u, err = random.UUIDv7()
+		default:
+			return fmt.Errorf("unsupported uuid version %d", *version)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(u)
+	}
+	return nil
+}
+
+func ulid(args []string) error {
+	fs := flag.NewFlagSet("ulid", flag.ExitOnError)
+	monotonic := fs.Bool("monotonic", false, "increase monotonically within the same millisecond")
+	count := fs.Int("count", 1, "number of ulids")
+	fs.Parse(args)
+
+	for i := 0; i < *count; i++ {
+		next := random.NewULID
+		if *monotonic {
+			next = random.NewMonotonicULID
+		}
+		u, err := next()
+		if err != nil {
+			return err
+		}
+		fmt.Println(u)
+	}
+	return nil
+}
+
+func nanoid(args []string) error {
+	fs := flag.NewFlagSet("nanoid", flag.ExitOnError)
+	alphabet := fs.String("alphabet", random.NanoIDAlphabet, "alphabet of the ids")
+	size := fs.Int("size", 21, "number of characters")
+	count := fs.Int("count", 1, "number of ids")
+	fs.Parse(args)
+
+	for i := 0; i < *count; i++ {
+		id, err := random.NanoID(*alphabet, *size)
+		if err != nil {
+			return err
+		}
+		fmt.Println(id)
+	}
+	return nil
 }
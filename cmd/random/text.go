@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/keng42/go/random"
+)
+
+// alphabets of the -format flag
+var formats = map[string]string{
+	"hex":          random.HexAlphabet,
+	"base64":       random.Uppercase + random.Lowercase + random.Digits + "+/",
+	"base64url":    random.Base64URLAlphabet,
+	"ascii85":      random.Ascii85Alphabet,
+	"alphanumeric": random.Digits + random.Uppercase + random.Lowercase,
+}
+
+// copySafe are the characters that need no quoting in a shell and survive double-click selection
+const copySafe = random.Digits + random.Uppercase + random.Lowercase + "-_."
+
+// text prints random texts as configured by the flags, e.g. random -f base64url -n 43
+func text(args []string) error {
+	fs := flag.NewFlagSet("random", flag.ExitOnError)
+	var format, alphabet string
+	var length, count int
+	fs.StringVar(&format, "format", "hex", "hex, base64, base64url, ascii85 or alphanumeric")
+	fs.StringVar(&format, "f", "hex", "shorthand for -format")
+	fs.StringVar(&alphabet, "alphabet", "", "custom alphabet, overrides -format")
+	fs.StringVar(&alphabet, "a", "", "shorthand for -alphabet")
+	fs.IntVar(&length, "length", 32, "number of characters")
+	fs.IntVar(&length, "n", 32, "shorthand for -length")
+	fs.IntVar(&count, "count", 1, "number of texts")
+	fs.IntVar(&count, "c", 1, "shorthand for -count")
+	asJSON := fs.Bool("json", false, "print a json object instead of lines")
+	noNewline := fs.Bool("no-newline", false, "omit the trailing newline")
+	copySafeOnly := fs.Bool("copy-safe", false, "use shell-safe characters only")
+	fs.Parse(args)
+
+	if alphabet == "" {
+		var ok bool
+		if alphabet, ok = formats[format]; !ok {
+			return fmt.Errorf("unsupported format %q", format)
+		}
+	} else {
+		format = "custom"
+	}
+	if *copySafeOnly {
+		alphabet = strings.Map(func(r rune) rune {
+			if strings.ContainsRune(copySafe, r) {
+				return r
+			}
+			return -1
+		}, alphabet)
+	}
+	// repeated characters would be picked more often and inflate the entropy
+	alphabet = uniqueRunes(alphabet)
+	if len([]rune(alphabet)) < 2 {
+		return fmt.Errorf("alphabet requires at least 2 distinct characters")
+	}
+	if count < 1 {
+		return fmt.Errorf("count must be at least 1")
+	}
+
+	texts := make([]string, count)
+	for i := range texts {
+		s, err := random.String(alphabet, length)
+		if err != nil {
+			return err
+		}
+		texts[i] = s
+	}
+
+	var out string
+	if *asJSON {
+		buf, err := json.Marshal(struct {
+			Format  string   `json:"format"`
+			Length  int      `json:"length"`
+			Entropy float64  `json:"entropy"`
+			Values  []string `json:"values"`
+		}{format, length, float64(length) * math.Log2(float64(len([]rune(alphabet)))), texts})
+		if err != nil {
+			return err
+		}
+		out = string(buf)
+	} else {
+		out = strings.Join(texts, "\n")
+	}
+	if !*noNewline {
+		out += "\n"
+	}
+
+	_, err := os.Stdout.WriteString(out)
+	return err
+}
+
+// uniqueRunes removes repeated characters of s, keeping the first occurrence
+func uniqueRunes(s string) string {
+	seen := map[rune]bool{}
+	return strings.Map(func(r rune) rune {
+		if seen[r] {
+			return -1
+		}
+		seen[r] = true
+		return r
+	}, s)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/keng42/go/random/randtest"
+)
+
+func main() {
+	alpha := flag.Float64("alpha", randtest.Alpha, "significance level, tests with a lower p-value fail")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: randtest [-alpha 0.01] [file], reads stdin if the file is omitted")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	in := io.Reader(os.Stdin)
+	if flag.NArg() > 0 {
+		f, err := os.Open(flag.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		in = f
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d bytes, alpha %g\n\n", len(data), *alpha)
+	fmt.Printf("%-24s %14s %10s  %s\n", "test", "statistic", "p-value", "result")
+
+	failed := false
+	for _, r := range randtest.Run(data, *alpha) {
+		if r.Err != nil {
+			fmt.Printf("%-24s %14s %10s  %s\n", r.Name, "-", "-", "skipped: "+r.Err.Error())
+			continue
+		}
+		if math.IsNaN(r.PValue) {
+			fmt.Printf("%-24s %14.6f %10s  %s\n", r.Name, r.Statistic, "-", "estimate")
+			continue
+		}
+		status := "pass"
+		if !r.Pass {
+			status = "FAIL"
+			failed = true
+		}
+		fmt.Printf("%-24s %14.6f %10.6f  %s\n", r.Name, r.Statistic, r.PValue, status)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/keng42/go/cnigma/shamir"
+)
+
+const usage = `usage:
+  shamir split -n 5 -k 3 [key]    split a base64 key, read from stdin if omitted
+  shamir combine [share...]       recover the key, shares are read from stdin if omitted`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "split":
+		err = split(os.Args[2:])
+	case "combine":
+		err = combine(os.Args[2:])
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func split(args []string) error {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	n := fs.Int("n", 5, "number of shares")
+	k := fs.Int("k", 3, "number of shares required to recover the key")
+	fs.Parse(args)
+
+	key := fs.Arg(0)
+	if key == "" {
+		lines, err := readLines(os.Stdin)
+		if err != nil {
+			return err
+		}
+		if len(lines) > 0 {
+			key = lines[0]
+		}
+	}
+
+	texts, err := shamir.SplitKey(key, *n, *k)
+	if err != nil {
+		return err
+	}
+	for _, text := range texts {
+		fmt.Println(text)
+	}
+	return nil
+}
+
+func combine(args []string) error {
+	fs := flag.NewFlagSet("combine", flag.ExitOnError)
+	fs.Parse(args)
+
+	texts := fs.Args()
+	if len(texts) == 0 {
+		lines, err := readLines(os.Stdin)
+		if err != nil {
+			return err
+		}
+		texts = lines
+	}
+
+	key, err := shamir.CombineKey(texts)
+	if err != nil {
+		return err
+	}
+	fmt.Println(key)
+	return nil
+}
+
+// readLines returns the non-empty lines of r
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -1,4 +1,4 @@
-// AES used to encrypt/decrypt text or file using aes-gcm or aes-cbc
+// AES used to encrypt/decrypt text or file using aes-gcm, aes-cbc, aes-siv or aes-gcm-siv
 //
 // created by keng42 @2020-12-04 10:30:05
 //
@@ -8,15 +8,21 @@ package aes
 import (
 	"encoding/base64"
 	"errors"
+	"fmt"
 
 	"github.com/keng42/go/cnigma/aes/cbc"
 	"github.com/keng42/go/cnigma/aes/gcm"
+	"github.com/keng42/go/cnigma/aes/gcmsiv"
+	"github.com/keng42/go/cnigma/aes/siv"
 	"github.com/keng42/go/cnigma/aes/types"
 	"github.com/keng42/go/cnigma/aes/utils"
+	"github.com/keng42/go/cnigma/mnemonic"
 )
 
-// NewAES returns a GCM or CBC instance depending on the mode parameter.
+// NewAES returns a GCM, CBC, SIV or GCMSIV instance depending on the mode parameter.
 // It's provide default value for all parameters except for the password in gcm mode.
+// The key is a base64 encoded string or its mnemonic words from the mnemonic package.
+// The siv mode requires a 256-bit, 384-bit or 512-bit key since aes-siv splits it in two halves.
 func NewAES(
 	mode types.ModeType,
 	key string,
@@ -30,24 +36,40 @@ func NewAES(
 	if mode == types.ModeGCM && password == "" {
 		return nil, errors.New("password is required in gcm mode")
 	}
-	if mode != types.ModeGCM && mode != types.ModeCBC {
-		return nil, errors.New("only support gcm and cbc mode")
+	if mode != types.ModeGCM && mode != types.ModeCBC && mode != types.ModeSIV && mode != types.ModeGCMSIV {
+		return nil, errors.New("only support gcm, cbc, siv and gcm-siv mode")
 	}
 
 	if key == "" {
 		key = types.DefaultKey
 	}
+	if mnemonic.IsMnemonic(key) {
+		var err error
+		if key, err = mnemonic.ToKey(key); err != nil {
+			return nil, err
+		}
+	}
 	keyBuf, err := base64.StdEncoding.DecodeString(key)
 	if err != nil {
 		return nil, err
 	}
 
 	keySize := len(keyBuf) * 8
-	if keySize != 256 {
-		if mode == types.ModeCBC {
+	switch mode {
+	case types.ModeCBC:
+		if keySize != 256 {
 			return nil, errors.New("key requires a 256-bit base64 encoded string with cbc mode")
 		}
-		if keySize != 128 && keySize != 192 {
+	case types.ModeSIV:
+		if keySize != 256 && keySize != 384 && keySize != 512 {
+			return nil, errors.New("key requires a 256-bit, 384-bit or 512-bit base64 encoded string with siv mode")
+		}
+	case types.ModeGCMSIV:
+		if keySize != 128 && keySize != 256 {
+			return nil, errors.New("key requires a 128-bit or 256-bit base64 encoded string with gcm-siv mode")
+		}
+	default:
+		if keySize != 128 && keySize != 192 && keySize != 256 {
 			return nil, errors.New("key requires a 128-bit, 192-bit or 256-bit base64 encoded string")
 		}
 	}
@@ -55,14 +77,32 @@ func NewAES(
 	if encoding == "" {
 		encoding = types.Base64
 	}
+	if !encoding.Valid() {
+		return nil, errors.New("unsupported encoding")
+	}
 
-	if mode == types.ModeGCM {
+	switch mode {
+	case types.ModeGCM:
 		return &gcm.GCM{
 			Key:      keyBuf,
 			Password: password,
 			Version:  []byte{0x01, 0x03},
 			Encoding: encoding,
 		}, nil
+	case types.ModeSIV:
+		return &siv.SIV{
+			Key:      keyBuf,
+			Password: password,
+			Version:  []byte{0x01, 0x05},
+			Encoding: encoding,
+		}, nil
+	case types.ModeGCMSIV:
+		return &gcmsiv.GCMSIV{
+			Key:      keyBuf,
+			Password: password,
+			Version:  []byte{0x01, 0x06},
+			Encoding: encoding,
+		}, nil
 	}
 	return &cbc.CBC{
 		Key:      keyBuf,
@@ -88,12 +128,32 @@ func NewCBC(
 	return NewAES(types.ModeCBC, key, "", encoding)
 }
 
+// NewSIV returns a SIV instance, the password is optional
+func NewSIV(
+	key string,
+	password string,
+	encoding types.EncodingType,
+) (types.AES, error) {
+	return NewAES(types.ModeSIV, key, password, encoding)
+}
+
+// NewGCMSIV returns a GCMSIV instance, the password is optional
+func NewGCMSIV(
+	key string,
+	password string,
+	encoding types.EncodingType,
+) (types.AES, error) {
+	return NewAES(types.ModeGCMSIV, key, password, encoding)
+}
+
+// NewKey returns a random base64 encoded key of the specified size in bits,
+// 384-bit and 512-bit keys are only useful in siv mode.
 func NewKey(size int) (string, error) {
 	if size == 0 {
 		size = 256
 	}
-	if size != 128 && size != 192 && size != 256 {
-		return "", errors.New("key size allow 128-bit, 192-bit or 256-bit only")
+	if size != 128 && size != 192 && size != 256 && size != 384 && size != 512 {
+		return "", errors.New("key size allow 128-bit, 192-bit, 256-bit, 384-bit or 512-bit only")
 	}
 
 	buf, err := utils.RandomBytes(size / 8)
@@ -104,3 +164,89 @@ func NewKey(size int) (string, error) {
 
 	return s, nil
 }
+
+// DeriveKey derives a base64 encoded key of the specified size in bits from a master key using HKDF-SHA256.
+// The same master key and context always give the same key,
+// so per-tenant or per-purpose keys don't have to be stored.
+func DeriveKey(master string, context string, size int) (string, error) {
+	if size == 0 {
+		size = 256
+	}
+	if size != 128 && size != 192 && size != 256 && size != 384 && size != 512 {
+		return "", errors.New("key size allow 128-bit, 192-bit, 256-bit, 384-bit or 512-bit only")
+	}
+
+	masterBuf, err := base64.StdEncoding.DecodeString(master)
+	if err != nil {
+		return "", err
+	}
+	if len(masterBuf) < 16 {
+		return "", errors.New("master key requires at least 128 bits")
+	}
+
+	// the size is part of the info so keys of different sizes are unrelated
+	info := fmt.Sprintf("cnigma-aes-%d:%s", size, context)
+	buf, err := utils.HKDF(masterBuf, nil, []byte(info), size/8)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// NewDerivedAES is the same as NewAES but uses a key derived from the master key and the context label.
+// The key size is the one required by the mode, 512-bit in siv mode and 256-bit otherwise.
+func NewDerivedAES(
+	mode types.ModeType,
+	master string,
+	context string,
+	password string,
+	encoding types.EncodingType,
+) (types.AES, error) {
+	size := 256
+	if mode == types.ModeSIV {
+		size = 512
+	}
+
+	key, err := DeriveKey(master, context, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAES(mode, key, password, encoding)
+}
+
+// DecryptText decrypts a ciphertext without knowing its mode in advance.
+// The mode is read from the version header of the ciphertext,
+// and the encoding is detected the same way as in auto mode, so armored text works too.
+func DecryptText(key, password, ciphertext string) (string, error) {
+	buf, err := utils.DecodeText(ciphertext, types.Auto, "", nil)
+	if err != nil {
+		return "", err
+	}
+
+	if len(buf) < 2 {
+		return "", errors.New("invalid ciphertext")
+	}
+
+	var mode types.ModeType
+	switch buf[1] {
+	case 0x02, 0x03: // 0x02 is used by ciphertexts from cnigma-ts
+		mode = types.ModeGCM
+	case 0x04:
+		mode = types.ModeCBC
+	case 0x05:
+		mode = types.ModeSIV
+	case 0x06:
+		mode = types.ModeGCMSIV
+	default:
+		return "", errors.New("unknown ciphertext version")
+	}
+
+	a, err := NewAES(mode, key, password, types.Auto)
+	if err != nil {
+		return "", err
+	}
+
+	return a.DecryptText(ciphertext, "")
+}
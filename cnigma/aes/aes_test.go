@@ -9,10 +9,12 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/keng42/go/cnigma/aes"
 	"github.com/keng42/go/cnigma/aes/types"
+	"github.com/keng42/go/cnigma/aes/utils"
 	"github.com/stretchr/testify/require"
 )
 
@@ -152,3 +154,288 @@ func TestNewKey(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, 32, len(keyBuf))
 }
+
+func TestHKDF(t *testing.T) {
+	// RFC 5869 test case 1
+	secret, _ := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+
+	okm, err := utils.HKDF(secret, salt, info, 42)
+	require.Nil(t, err)
+	require.Equal(t, "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865", hex.EncodeToString(okm))
+
+	// RFC 5869 test case 3, zero-length salt and info
+	okm, err = utils.HKDF(secret, nil, nil, 42)
+	require.Nil(t, err)
+	require.Equal(t, "8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8", hex.EncodeToString(okm))
+
+	_, err = utils.HKDF(secret, nil, nil, 255*32+1)
+	require.NotNil(t, err)
+}
+
+func TestDeriveKey(t *testing.T) {
+	tenantA, err := aes.DeriveKey(types.DefaultKey, "tenant-a", 256)
+	require.Nil(t, err)
+	again, err := aes.DeriveKey(types.DefaultKey, "tenant-a", 256)
+	require.Nil(t, err)
+	require.Equal(t, tenantA, again)
+
+	tenantB, err := aes.DeriveKey(types.DefaultKey, "tenant-b", 256)
+	require.Nil(t, err)
+	require.NotEqual(t, tenantA, tenantB)
+
+	short, err := aes.DeriveKey(types.DefaultKey, "tenant-a", 128)
+	require.Nil(t, err)
+	keyBuf, err := base64.StdEncoding.DecodeString(short)
+	require.Nil(t, err)
+	require.Equal(t, 16, len(keyBuf))
+	require.False(t, strings.HasPrefix(tenantA, short[:20]))
+
+	_, err = aes.DeriveKey(types.DefaultKey, "tenant-a", 100)
+	require.NotNil(t, err)
+	_, err = aes.DeriveKey("AAAA", "tenant-a", 256)
+	require.NotNil(t, err)
+
+	// the derived instance is the same as one built with the derived key
+	for _, mode := range []types.ModeType{types.ModeGCM, types.ModeCBC, types.ModeSIV, types.ModeGCMSIV} {
+		derived, err := aes.NewDerivedAES(mode, types.DefaultKey, "tenant-a", "my-password", types.Base64)
+		require.Nil(t, err)
+
+		ciphertext, err := derived.EncryptText("hello world @ 2020", "")
+		require.Nil(t, err)
+
+		size := 256
+		if mode == types.ModeSIV {
+			size = 512
+		}
+		key, err := aes.DeriveKey(types.DefaultKey, "tenant-a", size)
+		require.Nil(t, err)
+		a, err := aes.NewAES(mode, key, "my-password", types.Base64)
+		require.Nil(t, err)
+		plaintext, err := a.DecryptText(ciphertext, "")
+		require.Nil(t, err)
+		require.Equal(t, "hello world @ 2020", plaintext)
+
+		other, err := aes.NewDerivedAES(mode, types.DefaultKey, "tenant-b", "my-password", types.Base64)
+		require.Nil(t, err)
+		_, err = other.DecryptText(ciphertext, "")
+		require.NotNil(t, err)
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	encodings := []types.EncodingType{
+		types.Base64,
+		types.Base64URL,
+		types.Base64Raw,
+		types.Base64URLRaw,
+		types.Base32,
+		types.Hex,
+		types.Ascii85,
+		types.Z85,
+	}
+
+	auto, err := aes.NewAES(types.ModeGCM, "", "my-password", types.Auto)
+	require.Nil(t, err)
+
+	plaintext := "hello world @ 2020"
+	for _, mode := range []types.ModeType{types.ModeGCM, types.ModeCBC} {
+		detect, err := aes.NewAES(mode, "", "my-password", types.Auto)
+		require.Nil(t, err)
+
+		for _, encoding := range encodings {
+			a, err := aes.NewAES(mode, "", "my-password", encoding)
+			require.Nil(t, err)
+
+			ciphertext, err := a.EncryptText(plaintext, "")
+			require.Nil(t, err)
+
+			decrypted, err := a.DecryptText(ciphertext, "")
+			require.Nil(t, err)
+			require.Equal(t, plaintext, decrypted)
+
+			decrypted, err = detect.DecryptText(ciphertext, "")
+			require.Nil(t, err, encoding)
+			require.Equal(t, plaintext, decrypted)
+		}
+	}
+
+	// auto encodes as base64
+	ciphertext, err := auto.EncryptText(plaintext, "")
+	require.Nil(t, err)
+	_, err = base64.StdEncoding.DecodeString(ciphertext)
+	require.Nil(t, err)
+
+	_, err = aes.NewAES(types.ModeGCM, "", "my-password", "base58")
+	require.NotNil(t, err)
+}
+
+func TestArmor(t *testing.T) {
+	plaintext := "hello world @ 2020"
+
+	for _, mode := range []types.ModeType{types.ModeGCM, types.ModeCBC} {
+		a, err := aes.NewAES(mode, "", "my-password", types.Armor)
+		require.Nil(t, err)
+
+		ciphertext, err := a.EncryptText(plaintext, "")
+		require.Nil(t, err)
+		require.True(t, strings.HasPrefix(ciphertext, "-----BEGIN CNIGMA MESSAGE-----\nMode: "+string(mode)+"\n"))
+		// HKDF(key, info "cnigma-key-id") rather than a bare hash of the key
+		require.Contains(t, ciphertext, "\nKey-ID: ecc0a3cf6884bf9a\n")
+
+		decrypted, err := a.DecryptText(ciphertext, "")
+		require.Nil(t, err)
+		require.Equal(t, plaintext, decrypted)
+
+		// any encoding accepts armored text
+		hex, err := aes.NewAES(mode, "", "my-password", types.Hex)
+		require.Nil(t, err)
+		decrypted, err = hex.DecryptText(ciphertext, "")
+		require.Nil(t, err)
+		require.Equal(t, plaintext, decrypted)
+
+		// without knowing the mode
+		decrypted, err = aes.DecryptText("", "my-password", ciphertext)
+		require.Nil(t, err)
+		require.Equal(t, plaintext, decrypted)
+
+		// with another key
+		key, err := aes.NewKey(256)
+		require.Nil(t, err)
+		other, err := aes.NewAES(mode, key, "my-password", types.Base64)
+		require.Nil(t, err)
+		_, err = other.DecryptText(ciphertext, "")
+		require.NotNil(t, err)
+	}
+
+	cbc, err := aes.NewAES(types.ModeCBC, "", "", types.Armor)
+	require.Nil(t, err)
+	ciphertext, err := cbc.EncryptText(plaintext, "")
+	require.Nil(t, err)
+	gcm, err := aes.NewAES(types.ModeGCM, "", "my-password", types.Armor)
+	require.Nil(t, err)
+	_, err = gcm.DecryptText(ciphertext, "")
+	require.NotNil(t, err)
+
+	// the package level DecryptText detects plain encodings as well
+	decrypted, err := aes.DecryptText("", "my-password", "AQLV3eYPTOMhNec2Q69aY0Y3dOhbSTW4HMgmFucRugX5y9eY2nvXeMl/Zy8PVOpV")
+	require.Nil(t, err)
+	require.Equal(t, plaintext, decrypted)
+}
+
+type appendAES interface {
+	types.AES
+	types.Appender
+}
+
+// newAppender returns an AES instance of the mode, which implements Appender too
+func newAppender(tb testing.TB, mode types.ModeType) appendAES {
+	a, err := aes.NewAES(mode, "", "my-password", types.Base64)
+	require.Nil(tb, err)
+	ap, ok := a.(appendAES)
+	require.True(tb, ok, mode)
+	return ap
+}
+
+func TestAppend(t *testing.T) {
+	for _, mode := range []types.ModeType{types.ModeGCM, types.ModeCBC} {
+		a := newAppender(t, mode)
+
+		for size := 0; size <= 33; size++ {
+			plaintext := make([]byte, size)
+
+			prefix := []byte("prefix")
+			ciphertext, err := a.AppendEncrypt(prefix, plaintext)
+			require.Nil(t, err)
+			require.Equal(t, prefix, ciphertext[:len(prefix)])
+			require.LessOrEqual(t, len(ciphertext), len(prefix)+size+a.Overhead())
+
+			decrypted, err := a.DecryptBytes(ciphertext[len(prefix):], "")
+			require.Nil(t, err)
+			require.Equal(t, size, len(decrypted))
+
+			decrypted, err = a.AppendDecrypt(prefix, ciphertext[len(prefix):])
+			require.Nil(t, err)
+			require.Equal(t, append(prefix, plaintext...), decrypted)
+		}
+
+		_, err := a.AppendDecrypt(nil, []byte{0x01, 0x03})
+		require.NotNil(t, err)
+
+		plaintext := []byte("hello world @ 2020")
+		dst := make([]byte, 0, len(plaintext)+a.Overhead())
+		// cbc decrypts the padding into dst before removing it
+		out := make([]byte, 0, len(plaintext)+16)
+		allocs := testing.AllocsPerRun(100, func() {
+			ciphertext, err := a.AppendEncrypt(dst, plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := a.AppendDecrypt(out, ciphertext); err != nil {
+				t.Fatal(err)
+			}
+		})
+		require.Zero(t, allocs, mode)
+	}
+}
+
+func benchmarkEncrypt(b *testing.B, mode types.ModeType, size int, reuse bool) {
+	a := newAppender(b, mode)
+	var err error
+
+	plaintext := make([]byte, size)
+	dst := make([]byte, 0, size+a.Overhead())
+
+	b.ReportAllocs()
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if reuse {
+			_, err = a.AppendEncrypt(dst, plaintext)
+		} else {
+			_, err = a.EncryptBytes(plaintext, "")
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkDecrypt(b *testing.B, mode types.ModeType, size int, reuse bool) {
+	a := newAppender(b, mode)
+	var err error
+
+	ciphertext, err := a.EncryptBytes(make([]byte, size), "")
+	require.Nil(b, err)
+	dst := make([]byte, 0, size+16)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if reuse {
+			_, err = a.AppendDecrypt(dst, ciphertext)
+		} else {
+			_, err = a.DecryptBytes(ciphertext, "")
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGCMEncryptBytes(b *testing.B)  { benchmarkEncrypt(b, types.ModeGCM, 64, false) }
+func BenchmarkGCMAppendEncrypt(b *testing.B) { benchmarkEncrypt(b, types.ModeGCM, 64, true) }
+func BenchmarkGCMDecryptBytes(b *testing.B)  { benchmarkDecrypt(b, types.ModeGCM, 64, false) }
+func BenchmarkGCMAppendDecrypt(b *testing.B) { benchmarkDecrypt(b, types.ModeGCM, 64, true) }
+func BenchmarkCBCEncryptBytes(b *testing.B)  { benchmarkEncrypt(b, types.ModeCBC, 64, false) }
+func BenchmarkCBCAppendEncrypt(b *testing.B) { benchmarkEncrypt(b, types.ModeCBC, 64, true) }
+func BenchmarkCBCDecryptBytes(b *testing.B)  { benchmarkDecrypt(b, types.ModeCBC, 64, false) }
+func BenchmarkCBCAppendDecrypt(b *testing.B) { benchmarkDecrypt(b, types.ModeCBC, 64, true) }
+
+func TestDecryptTextUnversioned(t *testing.T) {
+	// 0x01 followed by an unknown version byte is not taken for a ciphertext
+	_, err := aes.DecryptText("", "my-password", hex.EncodeToString(append([]byte{0x01, 0xff}, make([]byte, 32)...)))
+	require.EqualError(t, err, "unable to detect encoding")
+}
@@ -8,21 +8,26 @@ package cbc
 import (
 	"crypto/aes"
 	"crypto/cipher"
-	"encoding/base64"
-	"encoding/hex"
+	"crypto/rand"
 	"errors"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/keng42/go/cnigma/aes/types"
 	"github.com/keng42/go/cnigma/aes/utils"
 )
 
-// CBC struct stores the default values required for the aes-cbc algorithm and implements the AES interface
+// CBC struct stores the default values required for the aes-cbc algorithm and implements the AES interface.
+// The block cipher is created on first use and cached, so Key must not be changed after that.
 type CBC struct {
 	Key      []byte
 	Version  []byte
 	Encoding types.EncodingType
+
+	once  sync.Once
+	block cipher.Block
+	err   error
 }
 
 const (
@@ -32,56 +37,70 @@ const (
 
 // EncryptBytes encrypt bytes using default key.
 // The return value ciphertext consists of 2 bytes of version information,
-// 14 bytes of nonce and encrypted data.
+// 16 bytes of iv and encrypted data.
 func (c *CBC) EncryptBytes(plaintext []byte, _ string) ([]byte, error) {
-	block, err := aes.NewCipher(c.Key)
-	if err != nil {
+	return c.AppendEncrypt(nil, plaintext)
+}
+
+// AppendEncrypt encrypts plaintext like EncryptBytes,
+// appends the result to dst and returns the updated slice.
+// No memory is allocated if dst has enough capacity for the ciphertext,
+// which is at most len(plaintext)+Overhead() bytes long.
+// dst and plaintext must not overlap.
+func (c *CBC) AppendEncrypt(dst, plaintext []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
 		return nil, err
 	}
 
-	plaintext = utils.PKCS7Padding(plaintext, aes.BlockSize)
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	n := len(c.Version) + IVSize
+	ret, out := utils.SliceForAppend(dst, n+len(plaintext)+padding)
 
-	iv, err := utils.RandomBytes(IVSize)
-	if err != nil {
+	copy(out, c.Version)
+	iv := out[len(c.Version):n]
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
 		return nil, err
 	}
 
-	encrypted := make([]byte, len(plaintext))
-	cbc := cipher.NewCBCEncrypter(block, iv)
-	cbc.CryptBlocks(encrypted, plaintext)
-
-	ciphertext := []byte{}
-	ciphertext = append(ciphertext, c.Version...)
-	ciphertext = append(ciphertext, iv...)
-	ciphertext = append(ciphertext, encrypted...)
-
-	return ciphertext, nil
-}
-
-// EncryptText encrypt text by calling EncryptBytes.
-func (c *CBC) EncryptText(plaintext string, _ string) (string, error) {
-	cipherBuf, err := c.EncryptBytes([]byte(plaintext), "")
-	if err != nil {
-		return "", err
+	encrypted := out[n:]
+	copy(encrypted, plaintext)
+	for i := len(plaintext); i < len(encrypted); i++ {
+		encrypted[i] = byte(padding)
 	}
 
-	var ciphertext string
-	if c.Encoding == types.Base64 {
-		ciphertext = base64.StdEncoding.EncodeToString(cipherBuf)
-	} else {
-		ciphertext = hex.EncodeToString(cipherBuf)
+	// chain the blocks by hand since cipher.NewCBCEncrypter allocates
+	prev := iv
+	for i := 0; i < len(encrypted); i += aes.BlockSize {
+		b := encrypted[i : i+aes.BlockSize]
+		for j := range b {
+			b[j] ^= prev[j]
+		}
+		c.block.Encrypt(b, b)
+		prev = b
 	}
 
-	return ciphertext, nil
+	return ret, nil
 }
 
 // DecryptBytes decrypt bytes using default key.
 func (c *CBC) DecryptBytes(ciphertext []byte, _ string) ([]byte, error) {
-	block, err := aes.NewCipher(c.Key)
-	if err != nil {
+	return c.AppendDecrypt(nil, ciphertext)
+}
+
+// AppendDecrypt decrypts ciphertext like DecryptBytes,
+// appends the result to dst and returns the updated slice.
+// The padding is decrypted into dst before it is removed, so no memory is allocated
+// if dst has enough capacity for the padded plaintext, which is len(ciphertext)-18 bytes long.
+// dst and ciphertext must not overlap.
+func (c *CBC) AppendDecrypt(dst, ciphertext []byte) ([]byte, error) {
+	if err := c.init(); err != nil {
 		return nil, err
 	}
 
+	if len(ciphertext) < 2+aes.BlockSize+aes.BlockSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
 	// versionBuf := ciphertext[0:2]
 	iv := ciphertext[2:(2 + aes.BlockSize)]
 	ciphertext = ciphertext[(2 + aes.BlockSize):]
@@ -91,25 +110,61 @@ func (c *CBC) DecryptBytes(ciphertext []byte, _ string) ([]byte, error) {
 		return nil, errors.New("ciphertext is not a multiple of the block size")
 	}
 
-	cbc := cipher.NewCBCDecrypter(block, iv)
+	ret, out := utils.SliceForAppend(dst, len(ciphertext))
+	copy(out, ciphertext)
 
-	// CryptBlocks can work in-place if the two arguments are the same.
-	cbc.CryptBlocks(ciphertext, ciphertext)
+	// decrypt backwards in place, so the previous block is still the ciphertext
+	for i := len(out) - aes.BlockSize; i >= 0; i -= aes.BlockSize {
+		b := out[i : i+aes.BlockSize]
+		c.block.Decrypt(b, b)
+		prev := iv
+		if i > 0 {
+			prev = out[i-aes.BlockSize : i]
+		}
+		for j := range b {
+			b[j] ^= prev[j]
+		}
+	}
 
-	plaintext := utils.PKCS7UnPadding(ciphertext)
+	padding := int(out[len(out)-1])
+	if padding == 0 || padding > aes.BlockSize {
+		return nil, errors.New("invalid padding")
+	}
+	for _, p := range out[len(out)-padding:] {
+		if int(p) != padding {
+			return nil, errors.New("invalid padding")
+		}
+	}
 
-	return plaintext, nil
+	return ret[:len(ret)-padding], nil
+}
+
+// Overhead returns the maximum difference between the lengths of a ciphertext and its plaintext
+func (c *CBC) Overhead() int {
+	return len(c.Version) + IVSize + aes.BlockSize
+}
+
+// init creates the block cipher once
+func (c *CBC) init() error {
+	c.once.Do(func() {
+		c.block, c.err = aes.NewCipher(c.Key)
+	})
+	return c.err
+}
+
+// EncryptText encrypt text by calling EncryptBytes.
+func (c *CBC) EncryptText(plaintext string, _ string) (string, error) {
+	cipherBuf, err := c.EncryptBytes([]byte(plaintext), "")
+	if err != nil {
+		return "", err
+	}
+
+	return utils.EncodeText(cipherBuf, c.Encoding, types.ModeCBC, c.Key)
 }
 
 // DecryptText decrypt text by calling DecryptBytes
 func (c *CBC) DecryptText(ciphertext string, _ string) (string, error) {
-	var cipherBuf []byte
-	var err error
-	if c.Encoding == types.Base64 {
-		cipherBuf, err = base64.StdEncoding.DecodeString(ciphertext)
-	} else {
-		cipherBuf, err = hex.DecodeString(ciphertext)
-	}
+	cipherBuf, err := utils.DecodeText(ciphertext, c.Encoding, types.ModeCBC, c.Key)
 	if err != nil {
 		return "", err
 	}
@@ -141,10 +196,10 @@ func (c *CBC) EncryptFile(src, dst, _ string) error {
 	}
 	defer outFile.Close()
 
-	block, err := aes.NewCipher(c.Key)
-	if err != nil {
+	if err := c.init(); err != nil {
 		return err
 	}
+	block := c.block
 
 	iv, err := utils.RandomBytes(aes.BlockSize)
 	if err != nil {
@@ -195,10 +250,10 @@ func (c *CBC) DecryptFile(src, dst, _ string) error {
 	}
 	defer outFile.Close()
 
-	block, err := aes.NewCipher(c.Key)
-	if err != nil {
+	if err := c.init(); err != nil {
 		return err
 	}
+	block := c.block
 
 	inBuf := make([]byte, 2+aes.BlockSize)
 	n, err := inFile.Read(inBuf)
@@ -6,23 +6,32 @@
 package gcm
 
 import (
+	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
-	"encoding/base64"
-	"encoding/hex"
+	"crypto/rand"
+	"errors"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/keng42/go/cnigma/aes/types"
 	"github.com/keng42/go/cnigma/aes/utils"
 )
 
-// GCM struct stores the default values required for the aes-gcm algorithm and implements the AES interface
+// GCM struct stores the default values required for the aes-gcm algorithm and implements the AES interface.
+// The cipher is created on first use and cached,
+// so Key, Password and Version must not be changed after that.
 type GCM struct {
 	Key      []byte
 	Password string
 	Version  []byte
 	Encoding types.EncodingType
+
+	once sync.Once
+	aead cipher.AEAD
+	aad  []byte // Version followed by Password
+	err  error
 }
 
 const (
@@ -34,89 +43,112 @@ const (
 // EncryptBytes encrypt bytes using default key and specify password.
 // If password is empty, use default password.
 // The return value ciphertext consists of 2 bytes of version information,
-// 14 bytes of nonce and encrypted data.
+// 12 bytes of nonce and encrypted data.
 func (g *GCM) EncryptBytes(plaintext []byte, password string) ([]byte, error) {
-	if password == "" {
-		password = g.Password
+	if err := g.init(); err != nil {
+		return nil, err
 	}
-	passwordBuf := []byte(password)
-
-	aad := append(g.Version, passwordBuf...)
+	return g.seal(nil, plaintext, g.additionalData(g.Version, password))
+}
 
-	gcm, err := gcmCipher(g.Key)
-	if err != nil {
+// AppendEncrypt encrypts plaintext using default key and default password like EncryptBytes,
+// appends the result to dst and returns the updated slice.
+// No memory is allocated if dst has enough capacity for the ciphertext,
+// which is len(plaintext)+Overhead() bytes long.
+// dst and plaintext must not overlap.
+func (g *GCM) AppendEncrypt(dst, plaintext []byte) ([]byte, error) {
+	if err := g.init(); err != nil {
 		return nil, err
 	}
+	return g.seal(dst, plaintext, g.aad)
+}
 
-	nonce, err := utils.RandomBytes(NonceSize)
-	if err != nil {
+// DecryptBytes decrypt bytes using default key and specify password.
+// If password is empty, use default password.
+func (g *GCM) DecryptBytes(ciphertext []byte, password string) ([]byte, error) {
+	if err := g.init(); err != nil {
 		return nil, err
 	}
-
-	encrypted := gcm.Seal(nil, nonce, plaintext, aad)
-
-	ciphertext := []byte{}
-	ciphertext = append(ciphertext, g.Version...)
-	ciphertext = append(ciphertext, nonce...)
-	ciphertext = append(ciphertext, encrypted...)
-
-	return ciphertext, nil
+	if len(ciphertext) < g.Overhead() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return g.open(nil, ciphertext, g.additionalData(ciphertext[:2], password))
 }
 
-// EncryptText encrypt text by calling EncryptBytes
-func (g *GCM) EncryptText(plaintext string, password string) (string, error) {
-	cipherBuf, err := g.EncryptBytes([]byte(plaintext), password)
-	if err != nil {
-		return "", err
+// AppendDecrypt decrypts ciphertext using default key and default password like DecryptBytes,
+// appends the result to dst and returns the updated slice.
+// No memory is allocated if dst has enough capacity for the plaintext.
+// dst and ciphertext must not overlap.
+func (g *GCM) AppendDecrypt(dst, ciphertext []byte) ([]byte, error) {
+	if err := g.init(); err != nil {
+		return nil, err
 	}
-
-	var ciphertext string
-	if g.Encoding == types.Base64 {
-		ciphertext = base64.StdEncoding.EncodeToString(cipherBuf)
-	} else {
-		ciphertext = hex.EncodeToString(cipherBuf)
+	if len(ciphertext) < g.Overhead() {
+		return nil, errors.New("ciphertext too short")
 	}
+	return g.open(dst, ciphertext, g.additionalData(ciphertext[:2], ""))
+}
 
-	return ciphertext, nil
+// Overhead returns the difference between the lengths of a ciphertext and its plaintext
+func (g *GCM) Overhead() int {
+	return len(g.Version) + NonceSize + AuthTagSize
 }
 
-// DecryptBytes decrypt bytes using default key and specify password.
-// If password is empty, use default password.
-func (g *GCM) DecryptBytes(ciphertext []byte, password string) ([]byte, error) {
+// init creates the cipher and the default additional data once
+func (g *GCM) init() error {
+	g.once.Do(func() {
+		g.aead, g.err = gcmCipher(g.Key)
+		g.aad = append(append([]byte{}, g.Version...), g.Password...)
+	})
+	return g.err
+}
+
+// additionalData returns the version followed by the password.
+// The cached value is returned for the default version and password.
+func (g *GCM) additionalData(version []byte, password string) []byte {
+	if (password == "" || password == g.Password) && bytes.Equal(version, g.Version) {
+		return g.aad
+	}
 	if password == "" {
 		password = g.Password
 	}
-	passwordBuf := []byte(password)
+	return append(append([]byte{}, version...), password...)
+}
 
-	gcm, err := gcmCipher(g.Key)
-	if err != nil {
+// seal appends version, a random nonce and the sealed plaintext to dst
+func (g *GCM) seal(dst, plaintext, aad []byte) ([]byte, error) {
+	n := len(g.Version) + NonceSize
+	ret, out := utils.SliceForAppend(dst, n+len(plaintext)+AuthTagSize)
+	copy(out, g.Version)
+	nonce := out[len(g.Version):n]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return nil, err
 	}
 
-	versionBuf := ciphertext[0:2]
+	return g.aead.Seal(ret[:len(dst)+n], nonce, plaintext, aad), nil
+}
+
+// open appends the plaintext of ciphertext to dst
+func (g *GCM) open(dst, ciphertext, aad []byte) ([]byte, error) {
 	nonce := ciphertext[2:(2 + NonceSize)]
 	encrypted := ciphertext[(2 + NonceSize):]
-	aad := []byte{}
-	aad = append(aad, versionBuf...)
-	aad = append(aad, passwordBuf...)
 
-	plain, err := gcm.Open(nil, nonce, encrypted, aad)
+	return g.aead.Open(dst, nonce, encrypted, aad)
+}
+
+// EncryptText encrypt text by calling EncryptBytes
+func (g *GCM) EncryptText(plaintext string, password string) (string, error) {
+	cipherBuf, err := g.EncryptBytes([]byte(plaintext), password)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	return plain, nil
+	return utils.EncodeText(cipherBuf, g.Encoding, types.ModeGCM, g.Key)
 }
 
 // DecryptText decrypt text by calling DecryptBytes
 func (g *GCM) DecryptText(ciphertext string, password string) (string, error) {
-	var cipherBuf []byte
-	var err error
-	if g.Encoding == types.Base64 {
-		cipherBuf, err = base64.StdEncoding.DecodeString(ciphertext)
-	} else {
-		cipherBuf, err = hex.DecodeString(ciphertext)
-	}
+	cipherBuf, err := utils.DecodeText(ciphertext, g.Encoding, types.ModeGCM, g.Key)
 	if err != nil {
 		return "", err
 	}
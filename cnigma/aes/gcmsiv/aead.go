@@ -0,0 +1,215 @@
+package gcmsiv
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/keng42/go/cnigma/aes/utils"
+)
+
+// aead implements AES-GCM-SIV as defined in RFC 8452
+type aead struct {
+	block   cipher.Block // the key-generating key
+	keySize int
+}
+
+// NewAEAD returns an AES-GCM-SIV cipher.AEAD for a 128-bit or 256-bit key
+func NewAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 16 && len(key) != 32 {
+		return nil, errors.New("aes-gcm-siv requires a 128-bit or 256-bit key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &aead{block: block, keySize: len(key)}, nil
+}
+
+func (a *aead) NonceSize() int {
+	return NonceSize
+}
+
+func (a *aead) Overhead() int {
+	return TagSize
+}
+
+func (a *aead) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != NonceSize {
+		panic("gcmsiv: incorrect nonce length given to AES-GCM-SIV")
+	}
+
+	authKey, block := a.deriveKeys(nonce)
+	tag := computeTag(authKey, block, nonce, plaintext, additionalData)
+
+	ret, out := utils.SliceForAppend(dst, len(plaintext)+TagSize)
+	ctr(block, tag, out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], tag[:])
+
+	return ret
+}
+
+func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		panic("gcmsiv: incorrect nonce length given to AES-GCM-SIV")
+	}
+	if len(ciphertext) < TagSize {
+		return nil, errors.New("cipher: message authentication failed")
+	}
+
+	var tag [TagSize]byte
+	copy(tag[:], ciphertext[len(ciphertext)-TagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-TagSize]
+
+	authKey, block := a.deriveKeys(nonce)
+	ret, out := utils.SliceForAppend(dst, len(ciphertext))
+	ctr(block, tag, out, ciphertext)
+
+	expected := computeTag(authKey, block, nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(expected[:], tag[:]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errors.New("cipher: message authentication failed")
+	}
+
+	return ret, nil
+}
+
+// deriveKeys derives the per-nonce message authentication key and message encryption key
+func (a *aead) deriveKeys(nonce []byte) ([16]byte, cipher.Block) {
+	var in, out [16]byte
+	copy(in[4:], nonce)
+
+	keys := make([]byte, 0, 16+a.keySize)
+	for i := uint32(0); len(keys) < cap(keys); i++ {
+		binary.LittleEndian.PutUint32(in[:4], i)
+		a.block.Encrypt(out[:], in[:])
+		keys = append(keys, out[:8]...)
+	}
+
+	var authKey [16]byte
+	copy(authKey[:], keys[:16])
+	block, err := aes.NewCipher(keys[16:])
+	if err != nil {
+		// the derived key always has a valid size
+		panic(err)
+	}
+	return authKey, block
+}
+
+// computeTag returns the tag of plaintext and additionalData
+func computeTag(authKey [16]byte, block cipher.Block, nonce, plaintext, additionalData []byte) [TagSize]byte {
+	p := newPolyval(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+	p.update(lengths[:])
+
+	s := p.sum()
+	for i := 0; i < NonceSize; i++ {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+
+	var tag [TagSize]byte
+	block.Encrypt(tag[:], s[:])
+	return tag
+}
+
+// ctr applies the 32-bit little endian counter mode of AES-GCM-SIV, starting from the tag
+func ctr(block cipher.Block, tag [TagSize]byte, dst, src []byte) {
+	counter := tag
+	counter[15] |= 0x80
+
+	var stream [16]byte
+	for i := 0; i < len(src); i += 16 {
+		block.Encrypt(stream[:], counter[:])
+		binary.LittleEndian.PutUint32(counter[:4], binary.LittleEndian.Uint32(counter[:4])+1)
+
+		end := i + 16
+		if end > len(src) {
+			end = len(src)
+		}
+		for j := i; j < end; j++ {
+			dst[j] = src[j] ^ stream[j-i]
+		}
+	}
+}
+
+// polyval computes POLYVAL through its relation with GHASH, see RFC 8452 appendix A:
+//
+//	POLYVAL(H, X_1, ..., X_n) = ByteReverse(GHASH(mulX_GHASH(ByteReverse(H)), ByteReverse(X_1), ..., ByteReverse(X_n)))
+type polyval struct {
+	h    gf128 // mulX_GHASH(ByteReverse(H))
+	s    gf128
+	tail []byte
+}
+
+// gf128 is an element of the GHASH field, hi holds the first 8 bytes in big endian order
+type gf128 struct {
+	hi, lo uint64
+}
+
+func newPolyval(key [16]byte) *polyval {
+	h := reversed(key[:])
+	return &polyval{h: mulX(h)}
+}
+
+// update absorbs buf zero padded to a multiple of 16 bytes
+func (p *polyval) update(buf []byte) {
+	for len(buf) > 0 {
+		var block [16]byte
+		n := copy(block[:], buf)
+		buf = buf[n:]
+
+		x := reversed(block[:])
+		p.s.hi ^= x.hi
+		p.s.lo ^= x.lo
+		p.s = mul(p.s, p.h)
+	}
+}
+
+func (p *polyval) sum() [16]byte {
+	var out [16]byte
+	binary.LittleEndian.PutUint64(out[:8], p.s.lo)
+	binary.LittleEndian.PutUint64(out[8:], p.s.hi)
+	return out
+}
+
+// reversed returns the element whose big endian bytes are the reverse of b
+func reversed(b []byte) gf128 {
+	return gf128{hi: binary.LittleEndian.Uint64(b[8:]), lo: binary.LittleEndian.Uint64(b[:8])}
+}
+
+// mulX multiplies v by x in the GHASH field
+func mulX(v gf128) gf128 {
+	lsb := v.lo & 1
+	v.lo = v.lo>>1 | v.hi<<63
+	v.hi >>= 1
+	v.hi ^= (0xe1 << 56) & -lsb
+	return v
+}
+
+// mul multiplies x and y in the GHASH field, see NIST SP 800-38D algorithm 1
+func mul(x, y gf128) gf128 {
+	var z gf128
+	v := y
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = x.hi >> (63 - i) & 1
+		} else {
+			bit = x.lo >> (127 - i) & 1
+		}
+		z.hi ^= v.hi & -bit
+		z.lo ^= v.lo & -bit
+		v = mulX(v)
+	}
+	return z
+}
@@ -0,0 +1,171 @@
+// GCMSIV struct and methods
+//
+
+package gcmsiv
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/keng42/go/cnigma/aes/types"
+	"github.com/keng42/go/cnigma/aes/utils"
+)
+
+// GCMSIV struct stores the default values required for the aes-gcm-siv algorithm and implements the AES interface.
+// Unlike aes-gcm, a repeated nonce only reveals whether two plaintexts are equal.
+// The cipher is created on first use and cached,
+// so Key, Password and Version must not be changed after that.
+type GCMSIV struct {
+	Key      []byte
+	Password string
+	Version  []byte
+	Encoding types.EncodingType
+
+	once sync.Once
+	aead cipher.AEAD
+	aad  []byte // Version followed by Password
+	err  error
+}
+
+const (
+	NonceSize      = 12        // standard nonce length for gcm-siv mode
+	TagSize        = 16        // auth tag length for gcm-siv mode
+	FileBufferSize = 16 * 1024 // default buffer size when reading file
+)
+
+// EncryptBytes encrypt bytes using default key and specify password.
+// If password is empty, use default password.
+// The return value ciphertext consists of 2 bytes of version information,
+// 12 bytes of nonce and encrypted data.
+func (g *GCMSIV) EncryptBytes(plaintext []byte, password string) ([]byte, error) {
+	if err := g.init(); err != nil {
+		return nil, err
+	}
+	return g.seal(nil, plaintext, g.additionalData(g.Version, password))
+}
+
+// AppendEncrypt encrypts plaintext using default key and default password like EncryptBytes,
+// appends the result to dst and returns the updated slice.
+// dst and plaintext must not overlap.
+func (g *GCMSIV) AppendEncrypt(dst, plaintext []byte) ([]byte, error) {
+	if err := g.init(); err != nil {
+		return nil, err
+	}
+	return g.seal(dst, plaintext, g.aad)
+}
+
+// EncryptText encrypt text by calling EncryptBytes
+func (g *GCMSIV) EncryptText(plaintext string, password string) (string, error) {
+	cipherBuf, err := g.EncryptBytes([]byte(plaintext), password)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.EncodeText(cipherBuf, g.Encoding, types.ModeGCMSIV, g.Key)
+}
+
+// DecryptBytes decrypt bytes using default key and specify password.
+// If password is empty, use default password.
+func (g *GCMSIV) DecryptBytes(ciphertext []byte, password string) ([]byte, error) {
+	if err := g.init(); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < g.Overhead() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return g.open(nil, ciphertext, g.additionalData(ciphertext[:2], password))
+}
+
+// AppendDecrypt decrypts ciphertext using default key and default password like DecryptBytes,
+// appends the result to dst and returns the updated slice.
+// dst and ciphertext must not overlap.
+func (g *GCMSIV) AppendDecrypt(dst, ciphertext []byte) ([]byte, error) {
+	if err := g.init(); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < g.Overhead() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return g.open(dst, ciphertext, g.additionalData(ciphertext[:2], ""))
+}
+
+// DecryptText decrypt text by calling DecryptBytes
+func (g *GCMSIV) DecryptText(ciphertext string, password string) (string, error) {
+	cipherBuf, err := utils.DecodeText(ciphertext, g.Encoding, types.ModeGCMSIV, g.Key)
+	if err != nil {
+		return "", err
+	}
+
+	plainBuf, err := g.DecryptBytes(cipherBuf, password)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plainBuf), nil
+}
+
+// EncryptFile encrypt the src file and save to the dst file using default key and specify password.
+// The file is divided into chunks that are encrypted separately.
+func (g *GCMSIV) EncryptFile(src, dst, password string) error {
+	return utils.EncryptFileChunks(src, dst, FileBufferSize, g.Overhead(), func(chunk []byte) ([]byte, error) {
+		return g.EncryptBytes(chunk, password)
+	})
+}
+
+// DecryptFile decrypt the src file and save to the dst file using default key and specify password.
+func (g *GCMSIV) DecryptFile(src, dst, password string) error {
+	return utils.DecryptFileChunks(src, dst, FileBufferSize, func(chunk []byte) ([]byte, error) {
+		return g.DecryptBytes(chunk, password)
+	})
+}
+
+// Overhead returns the difference between the lengths of a ciphertext and its plaintext
+func (g *GCMSIV) Overhead() int {
+	return len(g.Version) + NonceSize + TagSize
+}
+
+// init creates the cipher and the default additional data once
+func (g *GCMSIV) init() error {
+	g.once.Do(func() {
+		g.aead, g.err = NewAEAD(g.Key)
+		g.aad = append(append([]byte{}, g.Version...), g.Password...)
+	})
+	return g.err
+}
+
+// additionalData returns the version followed by the password.
+// The cached value is returned for the default version and password.
+func (g *GCMSIV) additionalData(version []byte, password string) []byte {
+	if (password == "" || password == g.Password) && bytes.Equal(version, g.Version) {
+		return g.aad
+	}
+	if password == "" {
+		password = g.Password
+	}
+	return append(append([]byte{}, version...), password...)
+}
+
+// seal appends version, a random nonce and the sealed plaintext to dst
+func (g *GCMSIV) seal(dst, plaintext, aad []byte) ([]byte, error) {
+	n := len(g.Version) + NonceSize
+	ret, out := utils.SliceForAppend(dst, n+len(plaintext)+TagSize)
+	copy(out, g.Version)
+	nonce := out[len(g.Version):n]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return g.aead.Seal(ret[:len(dst)+n], nonce, plaintext, aad), nil
+}
+
+// open appends the plaintext of ciphertext to dst
+func (g *GCMSIV) open(dst, ciphertext, aad []byte) ([]byte, error) {
+	nonce := ciphertext[2:(2 + NonceSize)]
+	encrypted := ciphertext[(2 + NonceSize):]
+
+	return g.aead.Open(dst, nonce, encrypted, aad)
+}
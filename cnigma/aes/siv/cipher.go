@@ -0,0 +1,162 @@
+package siv
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+)
+
+// Cipher implements AES-SIV as defined in RFC 5297
+type Cipher struct {
+	mac *cmac
+	ctr cipher.Block
+}
+
+// NewCipher returns an AES-SIV cipher.
+// The key is 32, 48 or 64 bytes long, the first half is used for S2V and the second half for CTR.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != 32 && len(key) != 48 && len(key) != 64 {
+		return nil, errors.New("aes-siv requires a 256-bit, 384-bit or 512-bit key")
+	}
+
+	macBlock, err := aes.NewCipher(key[:len(key)/2])
+	if err != nil {
+		return nil, err
+	}
+	ctrBlock, err := aes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{mac: newCMAC(macBlock), ctr: ctrBlock}, nil
+}
+
+// Seal encrypts and authenticates plaintext together with the associated data,
+// appends the synthetic iv followed by the encrypted data to dst and returns the updated slice.
+// A nonce, if any, is passed as the last associated data.
+func (c *Cipher) Seal(dst, plaintext []byte, ad ...[]byte) []byte {
+	v := c.s2v(ad, plaintext)
+
+	n := len(dst)
+	ret := append(dst, v[:]...)
+	ret = append(ret, plaintext...)
+	c.xorKeyStream(ret[n+IVSize:], v)
+
+	return ret
+}
+
+// Open decrypts and authenticates ciphertext like Seal,
+// appends the plaintext to dst and returns the updated slice.
+func (c *Cipher) Open(dst, ciphertext []byte, ad ...[]byte) ([]byte, error) {
+	if len(ciphertext) < IVSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	var v [IVSize]byte
+	copy(v[:], ciphertext)
+
+	n := len(dst)
+	ret := append(dst, ciphertext[IVSize:]...)
+	plaintext := ret[n:]
+	c.xorKeyStream(plaintext, v)
+
+	expected := c.s2v(ad, plaintext)
+	if subtle.ConstantTimeCompare(expected[:], v[:]) != 1 {
+		for i := range plaintext {
+			plaintext[i] = 0
+		}
+		return nil, errors.New("cipher: message authentication failed")
+	}
+
+	return ret, nil
+}
+
+// xorKeyStream applies CTR mode in place, using v with two bits cleared as the counter
+func (c *Cipher) xorKeyStream(buf []byte, v [IVSize]byte) {
+	v[8] &= 0x7f
+	v[12] &= 0x7f
+	cipher.NewCTR(c.ctr, v[:]).XORKeyStream(buf, buf)
+}
+
+// s2v implements the S2V construction of RFC 5297 section 2.4
+func (c *Cipher) s2v(ad [][]byte, plaintext []byte) [IVSize]byte {
+	var zero [IVSize]byte
+	d := c.mac.sum(zero[:])
+
+	for _, a := range ad {
+		d = dbl(d)
+		m := c.mac.sum(a)
+		xorBlock(&d, &m)
+	}
+
+	if len(plaintext) >= IVSize {
+		t := append([]byte{}, plaintext...)
+		end := t[len(t)-IVSize:]
+		for i := range end {
+			end[i] ^= d[i]
+		}
+		return c.mac.sum(t)
+	}
+
+	d = dbl(d)
+	var t [IVSize]byte
+	copy(t[:], plaintext)
+	t[len(plaintext)] = 0x80
+	xorBlock(&t, &d)
+	return c.mac.sum(t[:])
+}
+
+// cmac implements AES-CMAC as defined in RFC 4493
+type cmac struct {
+	block  cipher.Block
+	k1, k2 [16]byte
+}
+
+func newCMAC(block cipher.Block) *cmac {
+	var l [16]byte
+	block.Encrypt(l[:], l[:])
+	k1 := dbl(l)
+	return &cmac{block: block, k1: k1, k2: dbl(k1)}
+}
+
+func (c *cmac) sum(msg []byte) [16]byte {
+	var x [16]byte
+	for len(msg) > 16 {
+		for i := range x {
+			x[i] ^= msg[i]
+		}
+		c.block.Encrypt(x[:], x[:])
+		msg = msg[16:]
+	}
+
+	var last [16]byte
+	copy(last[:], msg)
+	if len(msg) == 16 {
+		xorBlock(&last, &c.k1)
+	} else {
+		last[len(msg)] = 0x80
+		xorBlock(&last, &c.k2)
+	}
+	xorBlock(&x, &last)
+	c.block.Encrypt(x[:], x[:])
+
+	return x
+}
+
+// dbl multiplies b by x in GF(2^128)
+func dbl(b [16]byte) [16]byte {
+	var out [16]byte
+	carry := b[0] >> 7
+	for i := 0; i < 15; i++ {
+		out[i] = b[i]<<1 | b[i+1]>>7
+	}
+	out[15] = b[15]<<1 ^ carry*0x87
+	return out
+}
+
+func xorBlock(dst, src *[16]byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
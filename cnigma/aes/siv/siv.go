@@ -0,0 +1,150 @@
+// SIV struct and methods
+//
+
+package siv
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/keng42/go/cnigma/aes/types"
+	"github.com/keng42/go/cnigma/aes/utils"
+)
+
+// SIV struct stores the default values required for the aes-siv algorithm and implements the AES interface.
+// Encryption is deterministic: the same key, password and plaintext always produce the same ciphertext.
+// The cipher is created on first use and cached,
+// so Key, Password and Version must not be changed after that.
+type SIV struct {
+	Key      []byte
+	Password string
+	Version  []byte
+	Encoding types.EncodingType
+
+	once sync.Once
+	siv  *Cipher
+	aad  []byte // Version followed by Password
+	err  error
+}
+
+const (
+	IVSize         = 16        // synthetic iv length
+	FileBufferSize = 16 * 1024 // default buffer size when reading file
+)
+
+// EncryptBytes encrypt bytes using default key and specify password.
+// If password is empty, use default password.
+// The return value ciphertext consists of 2 bytes of version information,
+// 16 bytes of synthetic iv and encrypted data.
+func (s *SIV) EncryptBytes(plaintext []byte, password string) ([]byte, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	dst := make([]byte, 0, len(plaintext)+s.Overhead())
+	dst = append(dst, s.Version...)
+	return s.siv.Seal(dst, plaintext, s.additionalData(s.Version, password)), nil
+}
+
+// AppendEncrypt encrypts plaintext using default key and default password like EncryptBytes,
+// appends the result to dst and returns the updated slice.
+// dst and plaintext must not overlap.
+func (s *SIV) AppendEncrypt(dst, plaintext []byte) ([]byte, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	dst = append(dst, s.Version...)
+	return s.siv.Seal(dst, plaintext, s.aad), nil
+}
+
+// EncryptText encrypt text by calling EncryptBytes
+func (s *SIV) EncryptText(plaintext string, password string) (string, error) {
+	cipherBuf, err := s.EncryptBytes([]byte(plaintext), password)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.EncodeText(cipherBuf, s.Encoding, types.ModeSIV, s.Key)
+}
+
+// DecryptBytes decrypt bytes using default key and specify password.
+// If password is empty, use default password.
+func (s *SIV) DecryptBytes(ciphertext []byte, password string) ([]byte, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < s.Overhead() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return s.siv.Open(nil, ciphertext[2:], s.additionalData(ciphertext[:2], password))
+}
+
+// AppendDecrypt decrypts ciphertext using default key and default password like DecryptBytes,
+// appends the result to dst and returns the updated slice.
+// dst and ciphertext must not overlap.
+func (s *SIV) AppendDecrypt(dst, ciphertext []byte) ([]byte, error) {
+	if err := s.init(); err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < s.Overhead() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return s.siv.Open(dst, ciphertext[2:], s.additionalData(ciphertext[:2], ""))
+}
+
+// DecryptText decrypt text by calling DecryptBytes
+func (s *SIV) DecryptText(ciphertext string, password string) (string, error) {
+	cipherBuf, err := utils.DecodeText(ciphertext, s.Encoding, types.ModeSIV, s.Key)
+	if err != nil {
+		return "", err
+	}
+
+	plainBuf, err := s.DecryptBytes(cipherBuf, password)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plainBuf), nil
+}
+
+// EncryptFile encrypt the src file and save to the dst file using default key and specify password.
+// The file is divided into chunks that are encrypted separately,
+// so equal chunks at the same offset of different files are visible in the ciphertext.
+func (s *SIV) EncryptFile(src, dst, password string) error {
+	return utils.EncryptFileChunks(src, dst, FileBufferSize, s.Overhead(), func(chunk []byte) ([]byte, error) {
+		return s.EncryptBytes(chunk, password)
+	})
+}
+
+// DecryptFile decrypt the src file and save to the dst file using default key and specify password.
+func (s *SIV) DecryptFile(src, dst, password string) error {
+	return utils.DecryptFileChunks(src, dst, FileBufferSize, func(chunk []byte) ([]byte, error) {
+		return s.DecryptBytes(chunk, password)
+	})
+}
+
+// Overhead returns the difference between the lengths of a ciphertext and its plaintext
+func (s *SIV) Overhead() int {
+	return len(s.Version) + IVSize
+}
+
+// init creates the cipher and the default additional data once
+func (s *SIV) init() error {
+	s.once.Do(func() {
+		s.siv, s.err = NewCipher(s.Key)
+		s.aad = append(append([]byte{}, s.Version...), s.Password...)
+	})
+	return s.err
+}
+
+// additionalData returns the version followed by the password.
+// The cached value is returned for the default version and password.
+func (s *SIV) additionalData(version []byte, password string) []byte {
+	if (password == "" || password == s.Password) && bytes.Equal(version, s.Version) {
+		return s.aad
+	}
+	if password == "" {
+		password = s.Password
+	}
+	return append(append([]byte{}, version...), password...)
+}
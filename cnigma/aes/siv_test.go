@@ -0,0 +1,213 @@
+package aes_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/keng42/go/cnigma/aes"
+	"github.com/keng42/go/cnigma/aes/gcmsiv"
+	"github.com/keng42/go/cnigma/aes/siv"
+	"github.com/keng42/go/cnigma/aes/types"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecodeBase64(t *testing.T, s string) []byte {
+	buf, err := base64.StdEncoding.DecodeString(s)
+	require.Nil(t, err)
+	return buf
+}
+
+func unhex(t *testing.T, s string) []byte {
+	buf, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	require.Nil(t, err)
+	return buf
+}
+
+// test vectors from RFC 5297 appendix A
+func TestSIVVectors(t *testing.T) {
+	// A.1 deterministic authenticated encryption
+	c, err := siv.NewCipher(unhex(t, "fffefdfc fbfaf9f8 f7f6f5f4 f3f2f1f0 f0f1f2f3 f4f5f6f7 f8f9fafb fcfdfeff"))
+	require.Nil(t, err)
+	ad := unhex(t, "10111213 14151617 18191a1b 1c1d1e1f 20212223 24252627")
+	plaintext := unhex(t, "11223344 55667788 99aabbcc ddee")
+	expected := unhex(t, "85632d07 c6e8f37f 950acd32 0a2ecc93 40c02b96 90c4dc04 daef7f6a fe5c")
+
+	ciphertext := c.Seal(nil, plaintext, ad)
+	require.Equal(t, expected, ciphertext)
+	decrypted, err := c.Open(nil, ciphertext, ad)
+	require.Nil(t, err)
+	require.Equal(t, plaintext, decrypted)
+
+	// A.2 nonce-based authenticated encryption
+	c, err = siv.NewCipher(unhex(t, "7f7e7d7c 7b7a7978 77767574 73727170 40414243 44454647 48494a4b 4c4d4e4f"))
+	require.Nil(t, err)
+	ad1 := unhex(t, "00112233 44556677 8899aabb ccddeeff deaddada deaddada ffeeddcc bbaa9988 77665544 33221100")
+	ad2 := unhex(t, "10203040 50607080 90a0")
+	nonce := unhex(t, "09f91102 9d74e35b d84156c5 635688c0")
+	plaintext = unhex(t, "74686973 20697320 736f6d65 20706c61 696e7465 78742074 6f20656e 63727970 74207573 696e6720 5349562d 414553")
+	expected = unhex(t, "7bdb6e3b 432667eb 06f4d14b ff2fbd0f cb900f2f ddbe4043 26601965 c889bf17 dba77ceb 094fa663 b7a3f748 ba8af829 ea64ad54 4a272e9c 485b62a3 fd5c0d")
+
+	ciphertext = c.Seal(nil, plaintext, ad1, ad2, nonce)
+	require.Equal(t, expected, ciphertext)
+	decrypted, err = c.Open(nil, ciphertext, ad1, ad2, nonce)
+	require.Nil(t, err)
+	require.Equal(t, plaintext, decrypted)
+
+	ciphertext[20] ^= 1
+	_, err = c.Open(nil, ciphertext, ad1, ad2, nonce)
+	require.NotNil(t, err)
+}
+
+// test vectors from RFC 8452 appendix C
+func TestGCMSIVVectors(t *testing.T) {
+	vectors := []struct {
+		key, nonce, aad, plaintext, result string
+	}{
+		{
+			key:    "01000000000000000000000000000000",
+			nonce:  "030000000000000000000000",
+			result: "dc20e2d83f25705bb49e439eca56de25",
+		},
+		{
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0100000000000000",
+			result:    "b5d839330ac7b786578782fff6013b815b287c22493a364c",
+		},
+		{
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "010000000000000000000000",
+			result:    "7323ea61d05932260047d942a4978db357391a0bc4fdec8b0d106639",
+		},
+		{
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "01000000000000000000000000000000",
+			result:    "743f7c8077ab25f8624e2e948579cf77303aaf90f6fe21199c6068577437a0c4",
+		},
+		{
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			aad:       "01",
+			plaintext: "0200000000000000",
+			result:    "1e6daba35669f4273b0a1a2560969cdf790d99759abd1508",
+		},
+		{
+			key:    "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:  "030000000000000000000000",
+			result: "07f5f4169bbf55a8400cd47ea6fd400f",
+		},
+		{
+			key:       "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0100000000000000",
+			result:    "c2ef328e5c71c83b843122130f7364b761e0b97427e3df28",
+		},
+	}
+
+	for i, v := range vectors {
+		aead, err := gcmsiv.NewAEAD(unhex(t, v.key))
+		require.Nil(t, err)
+
+		nonce := unhex(t, v.nonce)
+		aad := unhex(t, v.aad)
+		plaintext := unhex(t, v.plaintext)
+
+		ciphertext := aead.Seal(nil, nonce, plaintext, aad)
+		require.Equal(t, v.result, hex.EncodeToString(ciphertext), i)
+
+		decrypted, err := aead.Open(nil, nonce, ciphertext, aad)
+		require.Nil(t, err)
+		require.Equal(t, v.plaintext, hex.EncodeToString(decrypted))
+
+		ciphertext[0] ^= 1
+		_, err = aead.Open(nil, nonce, ciphertext, aad)
+		require.NotNil(t, err)
+	}
+}
+
+func TestSIVModes(t *testing.T) {
+	key512, err := aes.NewKey(512)
+	require.Nil(t, err)
+	key128, err := aes.NewKey(128)
+	require.Nil(t, err)
+
+	plaintext := "hello world @ 2020"
+	for _, c := range []struct {
+		mode types.ModeType
+		key  string
+	}{
+		{types.ModeSIV, ""},
+		{types.ModeSIV, key512},
+		{types.ModeGCMSIV, ""},
+		{types.ModeGCMSIV, key128},
+	} {
+		a, err := aes.NewAES(c.mode, c.key, "", types.Base64)
+		require.Nil(t, err)
+
+		ciphertext, err := a.EncryptText(plaintext, "")
+		require.Nil(t, err)
+		decrypted, err := a.DecryptText(ciphertext, "")
+		require.Nil(t, err)
+		require.Equal(t, plaintext, decrypted)
+
+		// the password is authenticated
+		ciphertext, err = a.EncryptText(plaintext, "my-password")
+		require.Nil(t, err)
+		_, err = a.DecryptText(ciphertext, "")
+		require.NotNil(t, err)
+		decrypted, err = a.DecryptText(ciphertext, "my-password")
+		require.Nil(t, err)
+		require.Equal(t, plaintext, decrypted)
+
+		decrypted, err = aes.DecryptText(c.key, "my-password", ciphertext)
+		require.Nil(t, err)
+		require.Equal(t, plaintext, decrypted)
+
+		ap, ok := a.(types.Appender)
+		require.True(t, ok)
+		buf, err := ap.AppendEncrypt([]byte("prefix"), []byte(plaintext))
+		require.Nil(t, err)
+		require.Equal(t, len("prefix")+len(plaintext)+ap.Overhead(), len(buf))
+		decryptedBuf, err := ap.AppendDecrypt(nil, buf[len("prefix"):])
+		require.Nil(t, err)
+		require.Equal(t, plaintext, string(decryptedBuf))
+	}
+
+	// siv is deterministic, gcm-siv is not
+	s, err := aes.NewSIV("", "", types.Base64)
+	require.Nil(t, err)
+	c1, _ := s.EncryptText(plaintext, "")
+	c2, _ := s.EncryptText(plaintext, "")
+	require.Equal(t, c1, c2)
+	require.Equal(t, byte(0x05), mustDecodeBase64(t, c1)[1])
+
+	g, err := aes.NewGCMSIV("", "", types.Base64)
+	require.Nil(t, err)
+	c1, _ = g.EncryptText(plaintext, "")
+	c2, _ = g.EncryptText(plaintext, "")
+	require.NotEqual(t, c1, c2)
+	require.Equal(t, byte(0x06), mustDecodeBase64(t, c1)[1])
+
+	_, err = aes.NewAES(types.ModeSIV, key128, "", types.Base64)
+	require.NotNil(t, err)
+	_, err = aes.NewAES(types.ModeGCMSIV, key512, "", types.Base64)
+	require.NotNil(t, err)
+}
+
+func TestSIVFile(t *testing.T) {
+	for _, mode := range []types.ModeType{types.ModeSIV, types.ModeGCMSIV} {
+		a, err := aes.NewAES(mode, "", "my-password", types.Base64)
+		require.Nil(t, err)
+
+		dir := t.TempDir()
+		err = a.EncryptFile("../testdata/xxy007.png", dir+"/xxy007.png.enc", "")
+		require.Nil(t, err)
+		err = a.DecryptFile(dir+"/xxy007.png.enc", dir+"/xxy007.png", "")
+		require.Nil(t, err)
+		require.Equal(t, fileHash("../testdata/xxy007.png"), fileHash(dir+"/xxy007.png"))
+	}
+}
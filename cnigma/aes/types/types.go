@@ -1,5 +1,7 @@
 package types
 
+import "github.com/keng42/go/cnigma/encoding"
+
 // AES interface used to provide a unified list of methods for aes-gcm and aes-cbc
 type AES interface {
 	EncryptBytes(plain []byte, password string) ([]byte, error)
@@ -10,16 +12,40 @@ type AES interface {
 	DecryptFile(src, dst, password string) error
 }
 
+// Appender is implemented by the AES instances of this module, assert it on an AES to reuse buffers:
+//
+//	if ap, ok := a.(types.Appender); ok { ... }
+type Appender interface {
+	// AppendEncrypt and AppendDecrypt work like EncryptBytes and DecryptBytes with the default password,
+	// but append the result to dst so that callers can reuse buffers.
+	AppendEncrypt(dst, plain []byte) ([]byte, error)
+	AppendDecrypt(dst, cipher []byte) ([]byte, error)
+	// Overhead returns the maximum difference between the lengths of a ciphertext and its plaintext
+	Overhead() int
+}
+
 type ModeType string
-type EncodingType string
+
+// EncodingType is the text encoding of EncryptText's output, see package encoding
+type EncodingType = encoding.Type
 
 // Constants used to limit NewAES's parameter values
 const (
-	ModeGCM    ModeType     = "gcm"
-	ModeCBC    ModeType     = "cbc"
-	Base64     EncodingType = "base64"
-	Hex        EncodingType = "hex"
-	DefaultKey string       = "7At16p/dyonmDW3ll9Pl1bmCsWEACxaIzLmyC0ZWGaE="
+	ModeGCM      ModeType     = "gcm"
+	ModeCBC      ModeType     = "cbc"
+	ModeSIV      ModeType     = "siv"     // deterministic aes-siv, RFC 5297
+	ModeGCMSIV   ModeType     = "gcm-siv" // nonce misuse resistant aes-gcm-siv, RFC 8452
+	Base64       EncodingType = encoding.Base64
+	Base64URL    EncodingType = encoding.Base64URL
+	Base64Raw    EncodingType = encoding.Base64Raw
+	Base64URLRaw EncodingType = encoding.Base64URLRaw
+	Base32       EncodingType = encoding.Base32
+	Hex          EncodingType = encoding.Hex
+	Ascii85      EncodingType = encoding.Ascii85
+	Z85          EncodingType = encoding.Z85
+	Auto         EncodingType = encoding.Auto
+	Armor        EncodingType = encoding.Armor
+	DefaultKey   string       = "7At16p/dyonmDW3ll9Pl1bmCsWEACxaIzLmyC0ZWGaE="
 )
 
 const (
@@ -3,7 +3,16 @@ package utils
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+
+	"github.com/keng42/go/cnigma/aes/types"
+	"github.com/keng42/go/cnigma/encoding"
+	"golang.org/x/crypto/hkdf"
 )
 
 // RandomBytes generate random bytes with specify size(bytes)
@@ -16,6 +25,41 @@ func RandomBytes(size int) ([]byte, error) {
 	return buf, nil
 }
 
+// HKDF derives size bytes from secret using HKDF-SHA256 (RFC 5869)
+func HKDF(secret, salt, info []byte, size int) ([]byte, error) {
+	if size <= 0 || size > 255*sha256.Size {
+		return nil, errors.New("hkdf output size is out of range")
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// KeyID returns the id of a secret key written in armor headers.
+// It's derived with HKDF under its own label instead of hashing the raw key,
+// so it doesn't match fingerprints of the same key computed elsewhere.
+func KeyID(key []byte) string {
+	id, _ := HKDF(key, nil, []byte("cnigma-key-id"), 8)
+	return hex.EncodeToString(id)
+}
+
+// SliceForAppend takes a slice and a requested number of bytes.
+// It returns a slice with the contents of the given slice followed by that many bytes
+// and a second slice that aliases into it and contains only the extra bytes.
+// If the original slice has sufficient capacity then no allocation is performed.
+func SliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
 // PKCS7Padding pad block using pkcs7
 func PKCS7Padding(ciphertext []byte, blockSize int) []byte {
 	padding := blockSize - len(ciphertext)%blockSize
@@ -29,3 +73,103 @@ func PKCS7UnPadding(origData []byte) []byte {
 	unpadding := int(origData[length-1])
 	return origData[:(length - unpadding)]
 }
+
+// EncodeText encodes a ciphertext produced in the specified mode with the specified key.
+// Armored output records the mode and the key id in its headers.
+func EncodeText(buf []byte, enc types.EncodingType, mode types.ModeType, key []byte) (string, error) {
+	if enc != types.Armor {
+		return enc.EncodeToString(buf)
+	}
+
+	block := &encoding.Block{
+		Type: encoding.ArmorMessage,
+		Headers: map[string]string{
+			encoding.HeaderMode:  string(mode),
+			encoding.HeaderKeyID: KeyID(key),
+		},
+		Bytes: buf,
+	}
+	return encoding.EncodeBlock(block, types.Base64)
+}
+
+// DecodeText decodes the text form of a ciphertext.
+// Armored text is accepted whatever the encoding is, as long as its headers match mode and key.
+// An empty mode or a nil key skips the corresponding check.
+// In auto mode the encoding is detected by looking for the version header of a ciphertext.
+func DecodeText(text string, enc types.EncodingType, mode types.ModeType, key []byte) ([]byte, error) {
+	if encoding.IsArmored(text) {
+		block, err := encoding.DecodeBlock(text)
+		if err != nil {
+			return nil, err
+		}
+		if block.Type != encoding.ArmorMessage {
+			return nil, errors.New("armored block is not a message")
+		}
+		if m := block.Headers[encoding.HeaderMode]; mode != "" && m != "" && m != string(mode) {
+			return nil, fmt.Errorf("armored message was encrypted in %s mode", m)
+		}
+		if id := block.Headers[encoding.HeaderKeyID]; key != nil && id != "" && id != KeyID(key) {
+			return nil, errors.New("armored message was encrypted with another key")
+		}
+		return block.Bytes, nil
+	}
+
+	if enc == types.Auto {
+		buf, _, err := encoding.DecodeAny(text, hasVersion)
+		return buf, err
+	}
+	return enc.DecodeString(text)
+}
+
+// hasVersion reports whether buf starts with a known version header,
+// 0x01 followed by 0x02 (cnigma-ts gcm) to 0x06 (gcm-siv)
+func hasVersion(buf []byte) bool {
+	return len(buf) > 2 && buf[0] == 0x01 && buf[1] >= 0x02 && buf[1] <= 0x06
+}
+
+// EncryptFileChunks encrypts the src file and saves to the dst file chunk by chunk.
+// Chunks are read overhead bytes shorter than size, so that every encrypted chunk is size bytes long
+// and DecryptFileChunks can split the dst file again.
+func EncryptFileChunks(src, dst string, size, overhead int, encrypt func([]byte) ([]byte, error)) error {
+	return transformFile(src, dst, size-overhead, encrypt)
+}
+
+// DecryptFileChunks decrypts the src file written by EncryptFileChunks and saves to the dst file
+func DecryptFileChunks(src, dst string, size int, decrypt func([]byte) ([]byte, error)) error {
+	return transformFile(src, dst, size, decrypt)
+}
+
+func transformFile(src, dst string, size int, fn func([]byte) ([]byte, error)) error {
+	inFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer inFile.Close()
+
+	outFile, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	buf := make([]byte, size)
+	for {
+		n, err := io.ReadFull(inFile, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		outBuf, err := fn(buf[:n])
+		if err != nil {
+			return err
+		}
+		if _, err := outFile.Write(outBuf); err != nil {
+			return err
+		}
+	}
+
+	return outFile.Close()
+}
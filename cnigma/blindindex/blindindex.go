@@ -0,0 +1,142 @@
+// Package blindindex computes keyed tokens of plaintext values,
+// so that columns encrypted with random nonces can still be searched for equality.
+//
+// Store Token(value) next to the output of EncryptText and query with
+//
+//	SELECT ... WHERE email_index = ?
+//
+// passing the token of the searched value. Tokens are truncated HMAC-SHA256 values:
+// fewer bits leak less about the plaintext but return more false positives,
+// which are filtered out after decryption.
+package blindindex
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	"github.com/keng42/go/cnigma/aes/utils"
+)
+
+// DefaultBits is the token size used when New is called with 0 bits
+const DefaultBits = 32
+
+// Index computes the tokens of one searchable column
+type Index struct {
+	key         []byte
+	bits        int
+	Normalizers []Normalizer
+}
+
+// New returns an Index named name.
+// The key is a base64 encoded key like the one passed to aes.NewAES.
+// The index key is derived from it and the name, so it's independent of the encryption key
+// and of the other indexes.
+// bits is the size of a token, from 1 to 256.
+func New(key string, name string, bits int, normalizers ...Normalizer) (*Index, error) {
+	keyBuf, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyBuf) < 16 {
+		return nil, errors.New("key requires at least 128 bits")
+	}
+	if name == "" {
+		return nil, errors.New("index name is required")
+	}
+	if bits == 0 {
+		bits = DefaultBits
+	}
+	if bits < 1 || bits > 256 {
+		return nil, errors.New("bits must be between 1 and 256")
+	}
+
+	// derived the same way as aes.DeriveKey, with a label of its own
+	indexKey, err := utils.HKDF(keyBuf, nil, []byte("cnigma-blindindex:"+name), sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Index{
+		key:         indexKey,
+		bits:        bits,
+		Normalizers: normalizers,
+	}, nil
+}
+
+// Bits returns the size of the tokens in bits
+func (i *Index) Bits() int {
+	return i.bits
+}
+
+// Token returns the token of the normalized value
+func (i *Index) Token(value string) string {
+	return i.tokenOf(i.normalize(value))
+}
+
+// Compound returns the token of several normalized values together,
+// for example Compound(lastName, birthYear).
+// Values are length prefixed, so ("ab", "c") and ("a", "bc") have different tokens.
+func (i *Index) Compound(values ...string) string {
+	mac := hmac.New(sha256.New, i.key)
+	var size [4]byte
+	for _, v := range values {
+		v = i.normalize(v)
+		binary.BigEndian.PutUint32(size[:], uint32(len(v)))
+		mac.Write(size[:])
+		mac.Write([]byte(v))
+	}
+	return i.truncate(mac.Sum(nil))
+}
+
+// Prefixes returns the tokens of every prefix of the normalized value
+// that is at least min characters long.
+// Store them in a separate table of an index with its own name,
+// and search with the Token of the typed prefix.
+// Short prefixes reveal a lot about the data, so keep min reasonably large.
+func (i *Index) Prefixes(value string, min int) []string {
+	value = i.normalize(value)
+	if min < 1 {
+		min = 1
+	}
+
+	tokens := []string{}
+	count := 0
+	for pos := range value {
+		if count >= min {
+			tokens = append(tokens, i.tokenOf(value[:pos]))
+		}
+		count++
+	}
+	if count >= min {
+		tokens = append(tokens, i.tokenOf(value))
+	}
+	return tokens
+}
+
+// tokenOf returns the token of an already normalized value
+func (i *Index) tokenOf(value string) string {
+	mac := hmac.New(sha256.New, i.key)
+	mac.Write([]byte(value))
+	return i.truncate(mac.Sum(nil))
+}
+
+func (i *Index) normalize(value string) string {
+	for _, n := range i.Normalizers {
+		value = n(value)
+	}
+	return value
+}
+
+// truncate keeps the first bits of sum and encodes them as hex
+func (i *Index) truncate(sum []byte) string {
+	n := (i.bits + 7) / 8
+	buf := sum[:n]
+	if rem := i.bits % 8; rem != 0 {
+		buf[n-1] &= byte(0xff << (8 - rem))
+	}
+	return hex.EncodeToString(buf)
+}
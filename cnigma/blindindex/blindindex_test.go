@@ -0,0 +1,93 @@
+package blindindex_test
+
+import (
+	"testing"
+
+	"github.com/keng42/go/cnigma/aes/types"
+	"github.com/keng42/go/cnigma/blindindex"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken(t *testing.T) {
+	email, err := blindindex.New(types.DefaultKey, "email", 64, blindindex.Trim, blindindex.CaseFold)
+	require.Nil(t, err)
+
+	token := email.Token("alice@example.com")
+	require.Len(t, token, 16)
+	require.Equal(t, token, email.Token("  Alice@Example.COM "))
+	require.NotEqual(t, token, email.Token("bob@example.com"))
+
+	// indexes with another name or key are independent
+	other, err := blindindex.New(types.DefaultKey, "login", 64, blindindex.Trim, blindindex.CaseFold)
+	require.Nil(t, err)
+	require.NotEqual(t, token, other.Token("alice@example.com"))
+
+	other, err = blindindex.New("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=", "email", 64, blindindex.Trim, blindindex.CaseFold)
+	require.Nil(t, err)
+	require.NotEqual(t, token, other.Token("alice@example.com"))
+
+	// truncation keeps a prefix of the full token
+	full, err := blindindex.New(types.DefaultKey, "email", 256, blindindex.Trim, blindindex.CaseFold)
+	require.Nil(t, err)
+	require.Len(t, full.Token("alice@example.com"), 64)
+	require.Equal(t, token, full.Token("alice@example.com")[:16])
+
+	short, err := blindindex.New(types.DefaultKey, "email", 12)
+	require.Nil(t, err)
+	token = short.Token("alice@example.com")
+	require.Len(t, token, 4)
+	require.Equal(t, byte('0'), token[3])
+	require.Equal(t, 12, short.Bits())
+
+	idx, err := blindindex.New(types.DefaultKey, "email", 0)
+	require.Nil(t, err)
+	require.Equal(t, blindindex.DefaultBits, idx.Bits())
+
+	_, err = blindindex.New(types.DefaultKey, "email", 257)
+	require.NotNil(t, err)
+	_, err = blindindex.New(types.DefaultKey, "", 0)
+	require.NotNil(t, err)
+	_, err = blindindex.New("c2hvcnQ=", "email", 0)
+	require.NotNil(t, err)
+}
+
+func TestCompound(t *testing.T) {
+	idx, err := blindindex.New(types.DefaultKey, "name_year", 0, blindindex.CaseFold)
+	require.Nil(t, err)
+
+	require.Equal(t, idx.Compound("Smith", "1970"), idx.Compound("SMITH", "1970"))
+	require.NotEqual(t, idx.Compound("ab", "c"), idx.Compound("a", "bc"))
+	require.NotEqual(t, idx.Compound("Smith", "1970"), idx.Compound("Smith", "1971"))
+}
+
+func TestPrefixes(t *testing.T) {
+	idx, err := blindindex.New(types.DefaultKey, "name_prefix", 0, blindindex.CaseFold)
+	require.Nil(t, err)
+
+	tokens := idx.Prefixes("Zoë Smith", 3)
+	require.Len(t, tokens, 7)
+	require.Equal(t, idx.Token("zoë"), tokens[0])
+	require.Contains(t, tokens, idx.Token("ZOË S"))
+	require.Equal(t, idx.Token("zoë smith"), tokens[6])
+
+	require.Empty(t, idx.Prefixes("ab", 3))
+}
+
+func TestNormalizers(t *testing.T) {
+	require.Equal(t, "a b c", blindindex.CollapseSpaces("  a \t b\n c "))
+	require.Equal(t, "5550100", blindindex.DigitsOnly("+(555) 01-00"))
+	require.Equal(t, blindindex.CaseFold("STRASSE ǅ"), blindindex.CaseFold("strasse ǆ"))
+}
+
+// tokens are stored, so the derivation must never change silently:
+// HMAC-SHA256(HKDF-SHA256(key, info "cnigma-blindindex:<name>"), value)
+func TestKnownAnswer(t *testing.T) {
+	full, err := blindindex.New(types.DefaultKey, "email", 256)
+	require.Nil(t, err)
+	require.Equal(t, "694fc99d93b1422c227f52b98ef386cb565906530d67c7a9d8a00f9b53885a8b", full.Token("alice@example.com"))
+
+	email, err := blindindex.New(types.DefaultKey, "email", 64, blindindex.Trim, blindindex.CaseFold)
+	require.Nil(t, err)
+	require.Equal(t, "3c1f9174f769ff2b", email.Token(" Alice@example.com"))
+	require.Equal(t, "14b5173b5ca2c9b1", email.Compound("alice", "2020"))
+}
@@ -0,0 +1,45 @@
+package blindindex
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Normalizer transforms a value before its token is computed,
+// so that values that should match produce the same token
+type Normalizer func(string) string
+
+// Trim removes leading and trailing white space
+func Trim(s string) string {
+	return strings.TrimSpace(s)
+}
+
+// CollapseSpaces replaces runs of white space with a single space and trims the result
+func CollapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// CaseFold maps every rune to a canonical case,
+// so that values which are equal under strings.EqualFold get the same token
+func CaseFold(s string) string {
+	return strings.Map(func(r rune) rune {
+		// the smallest rune of the simple folding orbit is the canonical one
+		min := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < min {
+				min = f
+			}
+		}
+		return min
+	}, s)
+}
+
+// DigitsOnly removes everything but decimal digits, useful for phone or card numbers
+func DigitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,195 @@
+package encoding
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Armor block types
+const (
+	ArmorMessage   = "CNIGMA MESSAGE"
+	ArmorSignature = "CNIGMA SIGNATURE"
+)
+
+// Armor header names
+const (
+	HeaderMode     = "Mode"
+	HeaderKeyID    = "Key-ID"
+	HeaderEncoding = "Encoding"
+)
+
+// ArmorLineLength is the number of characters per body line of an armored block
+const ArmorLineLength = 64
+
+// headerOrder lists the headers that are written before the others
+var headerOrder = []string{HeaderMode, HeaderKeyID, HeaderEncoding}
+
+// Block is an armored block like
+//
+//	-----BEGIN CNIGMA MESSAGE-----
+//	Mode: gcm
+//	Key-ID: 3f6c2ad5e7a2b1c4
+//	Encoding: base64
+//
+//	AQM...
+//	=njUN
+//	-----END CNIGMA MESSAGE-----
+//
+// The body is wrapped at ArmorLineLength characters and followed by the
+// base64 encoded CRC-24 checksum (RFC 4880) of Bytes.
+type Block struct {
+	Type    string
+	Headers map[string]string
+	Bytes   []byte
+}
+
+// EncodeBlock returns the armored form of b with its body encoded as body.
+// The Encoding header is always set to body.
+// Header keys and values must not contain line breaks or ": ",
+// so they can't forge other headers or end the header section early.
+func EncodeBlock(b *Block, body Type) (string, error) {
+	if body == Auto {
+		body = Base64
+	}
+	if body == Armor || !body.Valid() {
+		return "", unsupported(body)
+	}
+	text, err := body.EncodeToString(b.Bytes)
+	if err != nil {
+		return "", err
+	}
+
+	headers := map[string]string{}
+	for k, v := range b.Headers {
+		if k == "" || strings.ContainsAny(k+v, "\r\n") || strings.Contains(k, ":") || strings.Contains(v, ": ") {
+			return "", errors.New("armor: invalid header " + strconv.Quote(k))
+		}
+		headers[k] = v
+	}
+	headers[HeaderEncoding] = string(body)
+
+	var sb strings.Builder
+	sb.WriteString("-----BEGIN " + b.Type + "-----\n")
+	for _, k := range sortedHeaders(headers) {
+		sb.WriteString(k + ": " + headers[k] + "\n")
+	}
+	sb.WriteString("\n")
+	for len(text) > ArmorLineLength {
+		sb.WriteString(text[:ArmorLineLength] + "\n")
+		text = text[ArmorLineLength:]
+	}
+	if text != "" {
+		sb.WriteString(text + "\n")
+	}
+	sb.WriteString("=" + crc24Text(b.Bytes) + "\n")
+	sb.WriteString("-----END " + b.Type + "-----\n")
+
+	return sb.String(), nil
+}
+
+// IsArmored reports whether text looks like an armored block
+func IsArmored(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "-----BEGIN CNIGMA ")
+}
+
+// DecodeBlock parses an armored block and verifies its checksum.
+// Surrounding whitespace of every line is ignored,
+// so blocks that were indented or got CRLF line endings still decode.
+func DecodeBlock(text string) (*Block, error) {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+
+	begin := lines[0]
+	if !strings.HasPrefix(begin, "-----BEGIN ") || !strings.HasSuffix(begin, "-----") {
+		return nil, errors.New("armor: missing begin line")
+	}
+	typ := strings.TrimSuffix(strings.TrimPrefix(begin, "-----BEGIN "), "-----")
+	if len(lines) < 4 || lines[len(lines)-1] != "-----END "+typ+"-----" {
+		return nil, errors.New("armor: missing end line")
+	}
+	lines = lines[1 : len(lines)-1]
+
+	b := &Block{Type: typ, Headers: map[string]string{}}
+	for len(lines) > 0 && lines[0] != "" {
+		i := strings.Index(lines[0], ": ")
+		if i <= 0 {
+			return nil, errors.New("armor: invalid header line")
+		}
+		b.Headers[lines[0][:i]] = lines[0][i+2:]
+		lines = lines[1:]
+	}
+	if len(lines) < 2 {
+		return nil, errors.New("armor: missing body")
+	}
+	lines = lines[1:]
+
+	checksum := lines[len(lines)-1]
+	if len(checksum) != 5 || checksum[0] != '=' {
+		return nil, errors.New("armor: missing checksum")
+	}
+
+	body := Type(b.Headers[HeaderEncoding])
+	if body == "" {
+		body = Base64
+	}
+	if body == Armor || body == Auto {
+		return nil, unsupported(body)
+	}
+	buf, err := body.DecodeString(strings.Join(lines[:len(lines)-1], ""))
+	if err != nil {
+		return nil, err
+	}
+	if crc24Text(buf) != checksum[1:] {
+		return nil, errors.New("armor: checksum mismatch")
+	}
+	b.Bytes = buf
+
+	return b, nil
+}
+
+// KeyID returns a short fingerprint of a public key for armor headers.
+// It's a bare hash, so secret keys must use a keyed derivation such as utils.KeyID of the aes package instead.
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+func sortedHeaders(headers map[string]string) []string {
+	keys := []string{}
+	for _, k := range headerOrder {
+		if _, ok := headers[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	rest := []string{}
+	for k := range headers {
+		if k != HeaderMode && k != HeaderKeyID && k != HeaderEncoding {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// crc24Text returns the base64 encoded CRC-24 checksum defined in RFC 4880
+func crc24Text(buf []byte) string {
+	crc := uint32(0xb704ce)
+	for _, b := range buf {
+		crc ^= uint32(b) << 16
+		for i := 0; i < 8; i++ {
+			crc <<= 1
+			if crc&0x1000000 != 0 {
+				crc ^= 0x1864cfb
+			}
+		}
+	}
+	sum := []byte{byte(crc >> 16), byte(crc >> 8), byte(crc)}
+	return base64.StdEncoding.EncodeToString(sum)
+}
@@ -0,0 +1,167 @@
+// Package encoding provides the text encodings used to represent binary
+// ciphertexts and signatures as strings.
+package encoding
+
+import (
+	"encoding/ascii85"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Type names a text encoding
+type Type string
+
+// Supported encodings
+const (
+	Base64       Type = "base64"       // standard base64 with padding
+	Base64URL    Type = "base64url"    // url and file name safe base64 with padding
+	Base64Raw    Type = "base64raw"    // standard base64 without padding
+	Base64URLRaw Type = "base64urlraw" // url and file name safe base64 without padding
+	Base32       Type = "base32"       // standard base32 with padding
+	Hex          Type = "hex"          // lowercase hexadecimal
+	Ascii85      Type = "ascii85"      // btoa style ascii85 without delimiters
+	Z85          Type = "z85"          // ZeroMQ Z85, see z85.go for partial groups
+	Auto         Type = "auto"         // encode as base64, detect the encoding when decoding
+	Armor        Type = "armor"        // armored block with a base64 body, see armor.go
+)
+
+// detectOrder lists the candidates tried by Detect and DecodeAny.
+// Encodings with smaller alphabets come first since their strings are
+// usually valid in the larger ones as well.
+var detectOrder = []Type{
+	Hex,
+	Base32,
+	Base64,
+	Base64URL,
+	Base64Raw,
+	Base64URLRaw,
+	Z85,
+	Ascii85,
+}
+
+// Valid reports whether t is a supported encoding
+func (t Type) Valid() bool {
+	if t == Auto || t == Armor {
+		return true
+	}
+	for _, c := range detectOrder {
+		if t == c {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeToString encodes buf to text.
+// Auto encodes as Base64.
+func (t Type) EncodeToString(buf []byte) (string, error) {
+	switch t {
+	case Base64, Auto:
+		return base64.StdEncoding.EncodeToString(buf), nil
+	case Base64URL:
+		return base64.URLEncoding.EncodeToString(buf), nil
+	case Base64Raw:
+		return base64.RawStdEncoding.EncodeToString(buf), nil
+	case Base64URLRaw:
+		return base64.RawURLEncoding.EncodeToString(buf), nil
+	case Base32:
+		return base32.StdEncoding.EncodeToString(buf), nil
+	case Hex:
+		return hex.EncodeToString(buf), nil
+	case Ascii85:
+		out := make([]byte, ascii85.MaxEncodedLen(len(buf)))
+		n := ascii85.Encode(out, buf)
+		return string(out[:n]), nil
+	case Z85:
+		return z85Encode(buf), nil
+	case Armor:
+		return EncodeBlock(&Block{Type: ArmorMessage, Bytes: buf}, Base64)
+	}
+	return "", unsupported(t)
+}
+
+// DecodeString decodes text to bytes.
+// Auto detects the encoding, see DecodeAny.
+func (t Type) DecodeString(text string) ([]byte, error) {
+	switch t {
+	case Base64:
+		return base64.StdEncoding.DecodeString(text)
+	case Base64URL:
+		return base64.URLEncoding.DecodeString(text)
+	case Base64Raw:
+		return base64.RawStdEncoding.DecodeString(text)
+	case Base64URLRaw:
+		return base64.RawURLEncoding.DecodeString(text)
+	case Base32:
+		return base32.StdEncoding.DecodeString(text)
+	case Hex:
+		return hex.DecodeString(text)
+	case Ascii85:
+		return ascii85Decode(text)
+	case Z85:
+		return z85Decode(text)
+	case Armor:
+		b, err := DecodeBlock(text)
+		if err != nil {
+			return nil, err
+		}
+		return b.Bytes, nil
+	case Auto:
+		buf, _, err := DecodeAny(text, nil)
+		return buf, err
+	}
+	return nil, unsupported(t)
+}
+
+// Detect returns the first encoding that is able to decode text
+func Detect(text string) (Type, error) {
+	_, t, err := DecodeAny(text, nil)
+	return t, err
+}
+
+// DecodeAny tries every supported encoding in turn and returns the first result
+// that decodes without error and is accepted by the accept function.
+// Armored text is always decoded as Armor.
+// A nil accept function accepts everything.
+// Callers that know the shape of the expected bytes (a version header or a fixed length)
+// should pass an accept function to resolve strings that are valid in several encodings.
+func DecodeAny(text string, accept func([]byte) bool) ([]byte, Type, error) {
+	if text == "" {
+		return nil, "", errors.New("unable to detect encoding of empty text")
+	}
+	if IsArmored(text) {
+		buf, err := Armor.DecodeString(text)
+		if err == nil && accept != nil && !accept(buf) {
+			err = errors.New("armored block was rejected")
+		}
+		return buf, Armor, err
+	}
+	for _, t := range detectOrder {
+		buf, err := t.DecodeString(text)
+		if err != nil {
+			continue
+		}
+		if accept != nil && !accept(buf) {
+			continue
+		}
+		return buf, t, nil
+	}
+	return nil, "", errors.New("unable to detect encoding")
+}
+
+func ascii85Decode(text string) ([]byte, error) {
+	// every 'z' expands to 4 bytes, so this is the worst case
+	out := make([]byte, len(text)*4)
+	n, _, err := ascii85.Decode(out, []byte(text), true)
+	if err != nil {
+		return nil, err
+	}
+	return out[:n], nil
+}
+
+func unsupported(t Type) error {
+	return fmt.Errorf("unsupported encoding %q", string(t))
+}
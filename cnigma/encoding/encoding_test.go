@@ -0,0 +1,161 @@
+package encoding_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/keng42/go/cnigma/encoding"
+	"github.com/stretchr/testify/require"
+)
+
+var allTypes = []encoding.Type{
+	encoding.Base64,
+	encoding.Base64URL,
+	encoding.Base64Raw,
+	encoding.Base64URLRaw,
+	encoding.Base32,
+	encoding.Hex,
+	encoding.Ascii85,
+	encoding.Z85,
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, typ := range allTypes {
+		for size := 0; size <= 33; size++ {
+			buf := bytes.Repeat([]byte{0xfe, 0x00, 0x7f}, 11)[:size]
+
+			text, err := typ.EncodeToString(buf)
+			require.Nil(t, err, typ)
+
+			decoded, err := typ.DecodeString(text)
+			require.Nil(t, err, typ)
+			require.True(t, bytes.Equal(buf, decoded), typ)
+		}
+	}
+}
+
+func TestZ85(t *testing.T) {
+	// test vector from the Z85 specification
+	buf := []byte{0x86, 0x4F, 0xD2, 0x6F, 0xB5, 0x59, 0xF7, 0x5B}
+	text, err := encoding.Z85.EncodeToString(buf)
+	require.Nil(t, err)
+	require.Equal(t, "HelloWorld", text)
+
+	_, err = encoding.Z85.DecodeString("Hello~")
+	require.NotNil(t, err)
+	_, err = encoding.Z85.DecodeString("Hello1")
+	require.NotNil(t, err)
+}
+
+func TestValid(t *testing.T) {
+	for _, typ := range allTypes {
+		require.True(t, typ.Valid())
+	}
+	require.True(t, encoding.Auto.Valid())
+	require.False(t, encoding.Type("base58").Valid())
+	require.False(t, encoding.Type("").Valid())
+
+	_, err := encoding.Type("base58").EncodeToString([]byte("hi"))
+	require.NotNil(t, err)
+	_, err = encoding.Type("base58").DecodeString("hi")
+	require.NotNil(t, err)
+}
+
+func TestDetect(t *testing.T) {
+	typ, err := encoding.Detect("0103ff")
+	require.Nil(t, err)
+	require.Equal(t, encoding.Hex, typ)
+
+	typ, err = encoding.Detect("AQP/")
+	require.Nil(t, err)
+	require.Equal(t, encoding.Base64, typ)
+
+	typ, err = encoding.Detect("AQP_")
+	require.Nil(t, err)
+	require.Equal(t, encoding.Base64URL, typ)
+
+	_, err = encoding.Detect("")
+	require.NotNil(t, err)
+}
+
+func TestDecodeAny(t *testing.T) {
+	buf := []byte{0x01, 0x03, 0xfb, 0xef, 0xbe, 0x10, 0x42}
+	accept := func(b []byte) bool {
+		return bytes.Equal(b, buf)
+	}
+
+	for _, typ := range allTypes {
+		text, err := typ.EncodeToString(buf)
+		require.Nil(t, err)
+
+		decoded, detected, err := encoding.DecodeAny(text, accept)
+		require.Nil(t, err, typ)
+		require.Equal(t, buf, decoded, typ)
+
+		// several encodings share alphabets, only the bytes have to match
+		decoded, err = detected.DecodeString(text)
+		require.Nil(t, err)
+		require.Equal(t, buf, decoded)
+	}
+}
+
+func TestArmor(t *testing.T) {
+	buf := bytes.Repeat([]byte("hello world @ 2020 "), 5)
+	block := &encoding.Block{
+		Type:    encoding.ArmorMessage,
+		Headers: map[string]string{"Comment": "test", encoding.HeaderMode: "gcm"},
+		Bytes:   buf,
+	}
+
+	for _, body := range []encoding.Type{encoding.Base64, encoding.Hex, encoding.Z85} {
+		text, err := encoding.EncodeBlock(block, body)
+		require.Nil(t, err)
+		require.True(t, encoding.IsArmored(text))
+
+		lines := strings.Split(text, "\n")
+		require.Equal(t, "-----BEGIN CNIGMA MESSAGE-----", lines[0])
+		require.Equal(t, "Mode: gcm", lines[1])
+		require.Equal(t, "Encoding: "+string(body), lines[2])
+		require.Equal(t, "Comment: test", lines[3])
+		for _, line := range lines {
+			require.LessOrEqual(t, len(line), encoding.ArmorLineLength)
+		}
+
+		// indented with CRLF line endings, as pasted into a yaml file
+		pasted := "  " + strings.ReplaceAll(text, "\n", "\r\n  ")
+		decoded, err := encoding.DecodeBlock(pasted)
+		require.Nil(t, err)
+		require.Equal(t, block.Type, decoded.Type)
+		require.Equal(t, buf, decoded.Bytes)
+		require.Equal(t, "test", decoded.Headers["Comment"])
+	}
+
+	text, err := encoding.Armor.EncodeToString(buf)
+	require.Nil(t, err)
+	decoded, typ, err := encoding.DecodeAny(text, nil)
+	require.Nil(t, err)
+	require.Equal(t, encoding.Armor, typ)
+	require.Equal(t, buf, decoded)
+
+	corrupted := strings.Replace(text, "aGVsbG8", "aGVsbG9", 1)
+	_, err = encoding.Armor.DecodeString(corrupted)
+	require.NotNil(t, err)
+
+	_, err = encoding.Armor.DecodeString(strings.Replace(text, "-----END", "-----FIN", 1))
+	require.NotNil(t, err)
+
+	// headers that would inject other headers or end the header section
+	for k, v := range map[string]string{
+		"Comment":       "test\nKey-ID: 0000",
+		"Comment\r":     "test",
+		"Note":          "a\n\nAAAA",
+		"Key-ID: x":     "y",
+		"Comment: test": "",
+		"":              "empty",
+		"Comment2":      "a: b",
+	} {
+		_, err = encoding.EncodeBlock(&encoding.Block{Type: encoding.ArmorMessage, Headers: map[string]string{k: v}, Bytes: buf}, encoding.Base64)
+		require.NotNil(t, err, k)
+	}
+}
@@ -0,0 +1,81 @@
+package encoding
+
+import (
+	"errors"
+	"strings"
+)
+
+// z85Alphabet is the character set defined by https://rfc.zeromq.org/spec/32/
+const z85Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.-:+=^!/*?&<>()[]{}@%$#"
+
+// z85Encode encodes buf using Z85.
+// The specification only covers inputs that are a multiple of 4 bytes,
+// so a trailing partial group of n bytes is zero padded and written as n+1 characters,
+// the same way ascii85 handles it.
+func z85Encode(buf []byte) string {
+	var sb strings.Builder
+	sb.Grow((len(buf) + 3) / 4 * 5)
+
+	var chunk [5]byte
+	for len(buf) > 0 {
+		n := 4
+		if len(buf) < n {
+			n = len(buf)
+		}
+
+		var v uint32
+		for i := 0; i < 4; i++ {
+			v <<= 8
+			if i < n {
+				v |= uint32(buf[i])
+			}
+		}
+		for i := 4; i >= 0; i-- {
+			chunk[i] = z85Alphabet[v%85]
+			v /= 85
+		}
+
+		sb.Write(chunk[:n+1])
+		buf = buf[n:]
+	}
+
+	return sb.String()
+}
+
+// z85Decode decodes text produced by z85Encode
+func z85Decode(text string) ([]byte, error) {
+	if len(text)%5 == 1 {
+		return nil, errors.New("z85: invalid length")
+	}
+
+	out := make([]byte, 0, (len(text)+4)/5*4)
+	for len(text) > 0 {
+		n := 5
+		if len(text) < n {
+			n = len(text)
+		}
+
+		// a partial group is padded with the highest digit so that
+		// truncating the decoded value yields the original bytes
+		var v uint64
+		for i := 0; i < 5; i++ {
+			d := 84
+			if i < n {
+				d = strings.IndexByte(z85Alphabet, text[i])
+				if d < 0 {
+					return nil, errors.New("z85: invalid character")
+				}
+			}
+			v = v*85 + uint64(d)
+		}
+		if v > 0xffffffff {
+			return nil, errors.New("z85: group overflow")
+		}
+
+		group := []byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+		out = append(out, group[:n-1]...)
+		text = text[n:]
+	}
+
+	return out, nil
+}
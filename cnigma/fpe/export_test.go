@@ -0,0 +1,17 @@
+package fpe
+
+// FF3 exposes the original FF3 with a 64-bit tweak for the NIST sample vectors
+func FF3(key string, alphabet string, tweak []byte, text string, encrypt bool) (string, error) {
+	block, err := newBlock(key, true)
+	if err != nil {
+		return "", err
+	}
+	c, err := newCodec(alphabet)
+	if err != nil {
+		return "", err
+	}
+	return ff3(block, c, text, tweak, encrypt)
+}
+
+// ExpandTweak exposes the FF3-1 tweak mapping
+var ExpandTweak = expandTweak
@@ -0,0 +1,147 @@
+package fpe
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/big"
+)
+
+// FF1 implements the FF1 mode of NIST SP 800-38G
+type FF1 struct {
+	block cipher.Block
+	codec *codec
+	Tweak []byte // default tweak, may be empty
+}
+
+// NewFF1 returns an FF1 instance.
+// The alphabet defaults to Digits, and the tweak can be of any length including zero.
+func NewFF1(key string, tweak []byte, alphabet string) (*FF1, error) {
+	block, err := newBlock(key, false)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newCodec(alphabet)
+	if err != nil {
+		return nil, err
+	}
+	return &FF1{block: block, codec: c, Tweak: tweak}, nil
+}
+
+// Encrypt encrypts plaintext with the default tweak
+func (f *FF1) Encrypt(plaintext string) (string, error) {
+	return f.EncryptWithTweak(plaintext, f.Tweak)
+}
+
+// Decrypt decrypts ciphertext with the default tweak
+func (f *FF1) Decrypt(ciphertext string) (string, error) {
+	return f.DecryptWithTweak(ciphertext, f.Tweak)
+}
+
+// EncryptWithTweak encrypts plaintext with the specified tweak
+func (f *FF1) EncryptWithTweak(plaintext string, tweak []byte) (string, error) {
+	return f.crypt(plaintext, tweak, true)
+}
+
+// DecryptWithTweak decrypts ciphertext with the specified tweak
+func (f *FF1) DecryptWithTweak(ciphertext string, tweak []byte) (string, error) {
+	return f.crypt(ciphertext, tweak, false)
+}
+
+func (f *FF1) crypt(text string, tweak []byte, encrypt bool) (string, error) {
+	x, err := f.codec.numerals(text)
+	if err != nil {
+		return "", err
+	}
+	n := len(x)
+	if n < f.codec.minLength() || uint64(n) > math.MaxUint32 {
+		return "", errors.New("input length is out of range for the radix")
+	}
+
+	radix := f.codec.radix
+	u := n / 2
+	v := n - u
+	a, b := x[:u], x[u:]
+
+	byteLen := int(math.Ceil(math.Ceil(float64(v)*math.Log2(float64(radix))) / 8))
+	d := 4*((byteLen+3)/4) + 4
+
+	p := make([]byte, 16)
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3], p[4], p[5] = byte(radix>>16), byte(radix>>8), byte(radix)
+	p[6] = 10
+	p[7] = byte(u)
+	binary.BigEndian.PutUint32(p[8:], uint32(n))
+	binary.BigEndian.PutUint32(p[12:], uint32(len(tweak)))
+
+	padding := (16 - (len(tweak)+byteLen+1)%16) % 16
+	q := make([]byte, len(tweak)+padding+1+byteLen)
+	copy(q, tweak)
+
+	for j := 0; j < 10; j++ {
+		i := j
+		if !encrypt {
+			i = 9 - j
+		}
+
+		// the half that is fed to the round function
+		in := b
+		if !encrypt {
+			in = a
+		}
+		q[len(tweak)+padding] = byte(i)
+		numBytes := num(in, radix).Bytes()
+		for k := range q[len(q)-byteLen:] {
+			q[len(q)-byteLen+k] = 0
+		}
+		copy(q[len(q)-len(numBytes):], numBytes)
+
+		y := new(big.Int).SetBytes(f.roundOutput(p, q, d))
+
+		m := u
+		if i%2 == 1 {
+			m = v
+		}
+		modulus := pow(radix, m)
+
+		if encrypt {
+			c := num(a, radix)
+			c.Add(c, y)
+			c.Mod(c, modulus)
+			a, b = b, str(c, radix, m)
+		} else {
+			c := num(b, radix)
+			c.Sub(c, y)
+			c.Mod(c, modulus)
+			a, b = str(c, radix, m), a
+		}
+	}
+
+	return f.codec.text(append(append([]int{}, a...), b...)), nil
+}
+
+// roundOutput returns the first d bytes of the keystream derived from PRF(P || Q)
+func (f *FF1) roundOutput(p, q []byte, d int) []byte {
+	// PRF is CBC-MAC with a zero iv
+	r := make([]byte, 16)
+	for _, in := range [][]byte{p, q} {
+		for i := 0; i < len(in); i += 16 {
+			for k := 0; k < 16; k++ {
+				r[k] ^= in[i+k]
+			}
+			f.block.Encrypt(r, r)
+		}
+	}
+
+	s := append([]byte{}, r...)
+	block := make([]byte, 16)
+	for j := 1; len(s) < d; j++ {
+		copy(block, r)
+		binary.BigEndian.PutUint64(block[8:], binary.BigEndian.Uint64(r[8:])^uint64(j))
+		f.block.Encrypt(block, block)
+		s = append(s, block...)
+	}
+
+	return s[:d]
+}
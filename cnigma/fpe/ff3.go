@@ -0,0 +1,136 @@
+package fpe
+
+import (
+	"crypto/cipher"
+	"errors"
+	"math"
+	"math/big"
+)
+
+// FF31 implements the FF3-1 mode of NIST SP 800-38G Rev. 1,
+// the original FF3 with a 56-bit tweak is not exposed since it's no longer approved.
+type FF31 struct {
+	block cipher.Block
+	codec *codec
+	Tweak []byte // default tweak, must be 7 bytes
+}
+
+// NewFF31 returns an FF3-1 instance.
+// The alphabet defaults to Digits, and the tweak must be exactly 7 bytes.
+func NewFF31(key string, tweak []byte, alphabet string) (*FF31, error) {
+	if len(tweak) != 7 {
+		return nil, errors.New("ff3-1 requires a 56-bit tweak")
+	}
+	block, err := newBlock(key, true)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newCodec(alphabet)
+	if err != nil {
+		return nil, err
+	}
+	return &FF31{block: block, codec: c, Tweak: tweak}, nil
+}
+
+// Encrypt encrypts plaintext with the default tweak
+func (f *FF31) Encrypt(plaintext string) (string, error) {
+	return f.EncryptWithTweak(plaintext, f.Tweak)
+}
+
+// Decrypt decrypts ciphertext with the default tweak
+func (f *FF31) Decrypt(ciphertext string) (string, error) {
+	return f.DecryptWithTweak(ciphertext, f.Tweak)
+}
+
+// EncryptWithTweak encrypts plaintext with the specified 7 bytes tweak
+func (f *FF31) EncryptWithTweak(plaintext string, tweak []byte) (string, error) {
+	t, err := expandTweak(tweak)
+	if err != nil {
+		return "", err
+	}
+	return ff3(f.block, f.codec, plaintext, t, true)
+}
+
+// DecryptWithTweak decrypts ciphertext with the specified 7 bytes tweak
+func (f *FF31) DecryptWithTweak(ciphertext string, tweak []byte) (string, error) {
+	t, err := expandTweak(tweak)
+	if err != nil {
+		return "", err
+	}
+	return ff3(f.block, f.codec, ciphertext, t, false)
+}
+
+// expandTweak maps the 56-bit FF3-1 tweak to the 64-bit TL || TR of FF3
+func expandTweak(tweak []byte) ([]byte, error) {
+	if len(tweak) != 7 {
+		return nil, errors.New("ff3-1 requires a 56-bit tweak")
+	}
+	return []byte{
+		tweak[0], tweak[1], tweak[2], tweak[3] & 0xf0,
+		tweak[4], tweak[5], tweak[6], tweak[3] << 4,
+	}, nil
+}
+
+// ff3 runs the eight feistel rounds of FF3 with a 64-bit tweak,
+// block must be created from the byte-reversed key.
+func ff3(block cipher.Block, c *codec, text string, tweak []byte, encrypt bool) (string, error) {
+	x, err := c.numerals(text)
+	if err != nil {
+		return "", err
+	}
+	n := len(x)
+	radix := c.radix
+	maxLen := 2 * int(math.Floor(96/math.Log2(float64(radix))))
+	if n < c.minLength() || n > maxLen {
+		return "", errors.New("input length is out of range for the radix")
+	}
+
+	u := (n + 1) / 2
+	v := n - u
+	a, b := x[:u], x[u:]
+	tl, tr := tweak[:4], tweak[4:]
+
+	p := make([]byte, 16)
+	for j := 0; j < 8; j++ {
+		i := j
+		if !encrypt {
+			i = 7 - j
+		}
+
+		m, w := u, tr
+		if i%2 == 1 {
+			m, w = v, tl
+		}
+		in := b
+		if !encrypt {
+			in = a
+		}
+
+		copy(p, w)
+		p[3] ^= byte(i)
+		for k := 4; k < 16; k++ {
+			p[k] = 0
+		}
+		numBytes := num(reversedNumerals(in), radix).Bytes()
+		copy(p[16-len(numBytes):], numBytes)
+
+		s := reversedBytes(p)
+		block.Encrypt(s, s)
+		y := new(big.Int).SetBytes(reversedBytes(s))
+
+		modulus := pow(radix, m)
+		if encrypt {
+			cv := num(reversedNumerals(a), radix)
+			cv.Add(cv, y)
+			cv.Mod(cv, modulus)
+			a, b = b, reversedNumerals(str(cv, radix, m))
+		} else {
+			cv := num(reversedNumerals(b), radix)
+			cv.Sub(cv, y)
+			cv.Mod(cv, modulus)
+			a, b = reversedNumerals(str(cv, radix, m)), a
+		}
+	}
+
+	return c.text(append(append([]int{}, a...), b...)), nil
+}
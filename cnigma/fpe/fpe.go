@@ -0,0 +1,145 @@
+// Package fpe implements the format-preserving encryption modes FF1 and FF3-1
+// defined in NIST SP 800-38G Rev. 1.
+//
+// The ciphertext has the same length as the plaintext and uses the same alphabet,
+// so card numbers stay digits of the original length.
+// Like aes.NewAES, keys are base64 encoded 128-bit, 192-bit or 256-bit strings.
+package fpe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Common alphabets, the radix is the length of the alphabet
+const (
+	Digits       = "0123456789"
+	Hex          = "0123456789abcdef"
+	Alphanumeric = "0123456789abcdefghijklmnopqrstuvwxyz"
+	Base62       = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// newBlock decodes a base64 key and creates the aes block cipher
+func newBlock(key string, reverse bool) (cipher.Block, error) {
+	keyBuf, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+	if reverse {
+		keyBuf = reversedBytes(keyBuf)
+	}
+	keySize := len(keyBuf) * 8
+	if keySize != 128 && keySize != 192 && keySize != 256 {
+		return nil, errors.New("key requires a 128-bit, 192-bit or 256-bit base64 encoded string")
+	}
+	return aes.NewCipher(keyBuf)
+}
+
+// codec converts between strings and numeral strings of an alphabet
+type codec struct {
+	alphabet []rune
+	index    map[rune]int
+	radix    int
+}
+
+func newCodec(alphabet string) (*codec, error) {
+	if alphabet == "" {
+		alphabet = Digits
+	}
+	c := &codec{alphabet: []rune(alphabet), index: map[rune]int{}}
+	for i, r := range c.alphabet {
+		if _, ok := c.index[r]; ok {
+			return nil, errors.New("alphabet contains duplicate characters")
+		}
+		c.index[r] = i
+	}
+	c.radix = len(c.alphabet)
+	if c.radix < 2 || c.radix > 1<<16 {
+		return nil, errors.New("alphabet requires 2 to 65536 characters")
+	}
+	return c, nil
+}
+
+// numerals returns the numeral string of s
+func (c *codec) numerals(s string) ([]int, error) {
+	out := make([]int, 0, len(s))
+	for _, r := range s {
+		i, ok := c.index[r]
+		if !ok {
+			return nil, errors.New("input contains characters outside the alphabet")
+		}
+		out = append(out, i)
+	}
+	return out, nil
+}
+
+// text returns the string of the numeral string x
+func (c *codec) text(x []int) string {
+	var sb strings.Builder
+	for _, i := range x {
+		sb.WriteRune(c.alphabet[i])
+	}
+	return sb.String()
+}
+
+// minLength returns the smallest length with radix^minlen >= 1,000,000
+func (c *codec) minLength() int {
+	n := 1
+	v := big.NewInt(int64(c.radix))
+	r := big.NewInt(int64(c.radix))
+	million := big.NewInt(1000000)
+	for v.Cmp(million) < 0 {
+		v.Mul(v, r)
+		n++
+	}
+	return n
+}
+
+// num returns the number represented by the numeral string x, most significant numeral first
+func num(x []int, radix int) *big.Int {
+	r := big.NewInt(int64(radix))
+	v := new(big.Int)
+	for _, d := range x {
+		v.Mul(v, r)
+		v.Add(v, big.NewInt(int64(d)))
+	}
+	return v
+}
+
+// str returns the numeral string of v with m numerals, most significant numeral first
+func str(v *big.Int, radix, m int) []int {
+	out := make([]int, m)
+	r := big.NewInt(int64(radix))
+	v = new(big.Int).Set(v)
+	d := new(big.Int)
+	for i := m - 1; i >= 0; i-- {
+		v.DivMod(v, r, d)
+		out[i] = int(d.Int64())
+	}
+	return out
+}
+
+// pow returns radix^m
+func pow(radix, m int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(m)), nil)
+}
+
+func reversedBytes(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+func reversedNumerals(x []int) []int {
+	out := make([]int, len(x))
+	for i := range x {
+		out[len(x)-1-i] = x[i]
+	}
+	return out
+}
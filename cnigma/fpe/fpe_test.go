@@ -0,0 +1,148 @@
+package fpe_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/keng42/go/cnigma/fpe"
+	"github.com/stretchr/testify/require"
+)
+
+func hexKey(t *testing.T, s string) string {
+	buf, err := hex.DecodeString(s)
+	require.Nil(t, err)
+	return base64.StdEncoding.EncodeToString(buf)
+}
+
+func hexTweak(t *testing.T, s string) []byte {
+	buf, err := hex.DecodeString(s)
+	require.Nil(t, err)
+	return buf
+}
+
+// NIST SP 800-38G FF1 samples
+func TestFF1Samples(t *testing.T) {
+	const (
+		key128 = "2B7E151628AED2A6ABF7158809CF4F3C"
+		key192 = "2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F"
+		key256 = "2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94"
+	)
+	samples := []struct {
+		key, tweak, alphabet, plaintext, ciphertext string
+	}{
+		{key128, "", fpe.Digits, "0123456789", "2433477484"},
+		{key128, "39383736353433323130", fpe.Digits, "0123456789", "6124200773"},
+		{key128, "3737373770717273373737", fpe.Alphanumeric, "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{key192, "", fpe.Digits, "0123456789", "2830668132"},
+		{key192, "39383736353433323130", fpe.Digits, "0123456789", "2496655549"},
+		{key192, "3737373770717273373737", fpe.Alphanumeric, "0123456789abcdefghi", "xbj3kv35jrawxv32ysr"},
+		{key256, "", fpe.Digits, "0123456789", "6657667009"},
+		{key256, "39383736353433323130", fpe.Digits, "0123456789", "1001623463"},
+		{key256, "3737373770717273373737", fpe.Alphanumeric, "0123456789abcdefghi", "xs8a0azh2avyalyzuwd"},
+	}
+
+	for _, s := range samples {
+		f, err := fpe.NewFF1(hexKey(t, s.key), hexTweak(t, s.tweak), s.alphabet)
+		require.Nil(t, err)
+
+		ciphertext, err := f.Encrypt(s.plaintext)
+		require.Nil(t, err)
+		require.Equal(t, s.ciphertext, ciphertext)
+
+		plaintext, err := f.Decrypt(ciphertext)
+		require.Nil(t, err)
+		require.Equal(t, s.plaintext, plaintext)
+	}
+}
+
+// NIST SP 800-38G FF3 samples, FF3-1 shares the same rounds
+func TestFF3Samples(t *testing.T) {
+	const key = "EF4359D8D580AA4F7F036D6F04FC6A94"
+	samples := []struct {
+		tweak, alphabet, plaintext, ciphertext string
+	}{
+		{"D8E7920AFA330A73", fpe.Digits, "890121234567890000", "750918814058654607"},
+		{"9A768A92F60E12D8", fpe.Digits, "890121234567890000", "018989839189395384"},
+		{"D8E7920AFA330A73", fpe.Digits, "89012123456789000000789000000", "48598367162252569629397416226"},
+		{"0000000000000000", fpe.Digits, "89012123456789000000789000000", "34695224821734535122613701434"},
+		{"9A768A92F60E12D8", fpe.Alphanumeric[:26], "0123456789abcdefghi", "g2pk40i992fn20cjakb"},
+	}
+
+	for _, s := range samples {
+		ciphertext, err := fpe.FF3(hexKey(t, key), s.alphabet, hexTweak(t, s.tweak), s.plaintext, true)
+		require.Nil(t, err)
+		require.Equal(t, s.ciphertext, ciphertext)
+
+		plaintext, err := fpe.FF3(hexKey(t, key), s.alphabet, hexTweak(t, s.tweak), ciphertext, false)
+		require.Nil(t, err)
+		require.Equal(t, s.plaintext, plaintext)
+	}
+}
+
+func TestFF31(t *testing.T) {
+	key := hexKey(t, "EF4359D8D580AA4F7F036D6F04FC6A94")
+	tweak := hexTweak(t, "D8E7920AFA330A")
+
+	expanded, err := fpe.ExpandTweak(tweak)
+	require.Nil(t, err)
+	require.Equal(t, "d8e79200fa330aa0", hex.EncodeToString(expanded))
+
+	f, err := fpe.NewFF31(key, tweak, "")
+	require.Nil(t, err)
+
+	ciphertext, err := f.Encrypt("4111111111111111")
+	require.Nil(t, err)
+	require.Len(t, ciphertext, 16)
+	require.NotEqual(t, "4111111111111111", ciphertext)
+
+	expected, err := fpe.FF3(key, fpe.Digits, expanded, "4111111111111111", true)
+	require.Nil(t, err)
+	require.Equal(t, expected, ciphertext)
+
+	plaintext, err := f.Decrypt(ciphertext)
+	require.Nil(t, err)
+	require.Equal(t, "4111111111111111", plaintext)
+
+	// another tweak gives another ciphertext
+	other, err := f.EncryptWithTweak("4111111111111111", hexTweak(t, "00000000000000"))
+	require.Nil(t, err)
+	require.NotEqual(t, ciphertext, other)
+
+	_, err = fpe.NewFF31(key, hexTweak(t, "D8E7920AFA330A73"), "")
+	require.NotNil(t, err)
+}
+
+func TestErrors(t *testing.T) {
+	key := hexKey(t, "2B7E151628AED2A6ABF7158809CF4F3C")
+
+	_, err := fpe.NewFF1("invalid", nil, "")
+	require.NotNil(t, err)
+
+	_, err = fpe.NewFF1(key, nil, "0")
+	require.NotNil(t, err)
+
+	_, err = fpe.NewFF1(key, nil, "0012")
+	require.NotNil(t, err)
+
+	f, err := fpe.NewFF1(key, nil, "")
+	require.Nil(t, err)
+
+	// characters outside the alphabet
+	_, err = f.Encrypt("01234abcde")
+	require.NotNil(t, err)
+
+	// too short for the radix, 10^5 < 1,000,000
+	_, err = f.Encrypt("12345")
+	require.NotNil(t, err)
+
+	// unicode alphabets work too
+	f, err = fpe.NewFF1(key, []byte("tweak"), "αβγδεζηθικλμνξοπρστυφχψω")
+	require.Nil(t, err)
+	ciphertext, err := f.Encrypt("αβγδεζηθ")
+	require.Nil(t, err)
+	require.Len(t, []rune(ciphertext), 8)
+	plaintext, err := f.Decrypt(ciphertext)
+	require.Nil(t, err)
+	require.Equal(t, "αβγδεζηθ", plaintext)
+}
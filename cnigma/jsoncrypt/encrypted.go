@@ -0,0 +1,79 @@
+package jsoncrypt
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/keng42/go/cnigma/aes/types"
+)
+
+var (
+	mu         sync.RWMutex
+	registered *Codec
+)
+
+// Register sets the codec used by EncryptedString and Encrypted.
+// It's usually called once during startup.
+func Register(c *Codec) {
+	mu.Lock()
+	defer mu.Unlock()
+	registered = c
+}
+
+// registeredAES returns the AES instance and password of the registered codec
+func registeredAES() (types.AES, string, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if registered == nil || registered.AES == nil {
+		return nil, "", errors.New("jsoncrypt: no codec registered")
+	}
+	return registered.AES, registered.Password, nil
+}
+
+// EncryptedString is a string that is encrypted with the registered codec
+// when marshaled to json and decrypted when unmarshaled.
+// It works with plain encoding/json, no tags required.
+type EncryptedString string
+
+// MarshalJSON implements json.Marshaler
+func (s EncryptedString) MarshalJSON() ([]byte, error) {
+	aes, password, err := registeredAES()
+	if err != nil {
+		return nil, err
+	}
+	return encrypt(aes, password, reflect.ValueOf(string(s)))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *EncryptedString) UnmarshalJSON(data []byte) error {
+	aes, password, err := registeredAES()
+	if err != nil {
+		return err
+	}
+	return decrypt(aes, password, data, reflect.ValueOf(s).Elem())
+}
+
+// Encrypted holds a value that is encrypted with the registered codec
+// when marshaled to json and decrypted when unmarshaled.
+type Encrypted[T any] struct {
+	Value T
+}
+
+// MarshalJSON implements json.Marshaler
+func (e Encrypted[T]) MarshalJSON() ([]byte, error) {
+	aes, password, err := registeredAES()
+	if err != nil {
+		return nil, err
+	}
+	return encrypt(aes, password, reflect.ValueOf(&e.Value).Elem())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (e *Encrypted[T]) UnmarshalJSON(data []byte) error {
+	aes, password, err := registeredAES()
+	if err != nil {
+		return err
+	}
+	return decrypt(aes, password, data, reflect.ValueOf(&e.Value).Elem())
+}
@@ -0,0 +1,588 @@
+// Package jsoncrypt encrypts selected fields during json marshaling.
+//
+// Struct fields tagged with `cnigma:"encrypt"` are encrypted by Codec.Marshal
+// and decrypted by Codec.Unmarshal, everything else is handled like encoding/json does.
+// String values are encrypted as they are, other values are encrypted in their json form,
+// and the ciphertext always ends up as a json string.
+//
+//	type User struct {
+//		Name  string `json:"name"`
+//		Email string `json:"email" cnigma:"encrypt"`
+//	}
+package jsoncrypt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/keng42/go/cnigma/aes/types"
+)
+
+// TagName is the struct tag used to mark fields that should be encrypted
+const TagName = "cnigma"
+
+// Codec marshals and unmarshals json, encrypting tagged fields with AES
+type Codec struct {
+	AES      types.AES
+	Password string // optional, the password of AES is used if empty
+}
+
+// New returns a Codec using the specified AES instance
+func New(aes types.AES) *Codec {
+	return &Codec{AES: aes}
+}
+
+// Marshal returns the json encoding of v with tagged fields encrypted
+func (c *Codec) Marshal(v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := c.marshal(buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal parses json into v, which must be a non-nil pointer, decrypting tagged fields
+func (c *Codec) Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("jsoncrypt: Unmarshal requires a non-nil pointer")
+	}
+	return c.unmarshal(data, rv.Elem())
+}
+
+func (c *Codec) marshal(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	if ok, err := hasTagged(v.Type()); err != nil || !ok {
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(b)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return c.marshal(buf, v.Elem())
+
+	case reflect.Struct:
+		buf.WriteByte('{')
+		first := true
+		for _, f := range fieldsOf(v.Type()) {
+			fv, err := v.FieldByIndexErr(f.index)
+			if err != nil {
+				// nil embedded pointer, encoding/json skips its fields too
+				continue
+			}
+			if f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+
+			name, _ := json.Marshal(f.name)
+			buf.Write(name)
+			buf.WriteByte(':')
+			if f.encrypt {
+				b, err := encrypt(c.AES, c.Password, fv)
+				if err != nil {
+					return fmt.Errorf("jsoncrypt: field %s: %w", f.name, err)
+				}
+				buf.Write(b)
+				continue
+			}
+			if f.quoted {
+				b, err := marshalQuoted(fv)
+				if err != nil {
+					return err
+				}
+				buf.Write(b)
+				continue
+			}
+			if err := c.marshal(buf, fv); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := c.marshal(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name, _ := json.Marshal(k.String())
+			buf.Write(name)
+			buf.WriteByte(':')
+			if err := c.marshal(buf, v.MapIndex(k)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+
+	return nil
+}
+
+func (c *Codec) unmarshal(data []byte, v reflect.Value) error {
+	if ok, err := hasTagged(v.Type()); err != nil || !ok {
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+	if string(bytes.TrimSpace(data)) == "null" {
+		if v.Kind() != reflect.Struct && v.Kind() != reflect.Array {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return c.unmarshal(data, v.Elem())
+
+	case reflect.Interface:
+		// like encoding/json, decode into the value a non-nil pointer points to
+		if !v.IsNil() && v.Elem().Kind() == reflect.Pointer && !v.Elem().IsNil() {
+			return c.unmarshal(data, v.Elem().Elem())
+		}
+		return json.Unmarshal(data, v.Addr().Interface())
+
+	case reflect.Struct:
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		for _, f := range fieldsOf(v.Type()) {
+			raw, ok := lookup(m, f.name)
+			if !ok {
+				continue
+			}
+			fv := fieldByIndexAlloc(v, f.index)
+			if f.encrypt {
+				if err := decrypt(c.AES, c.Password, raw, fv); err != nil {
+					return fmt.Errorf("jsoncrypt: field %s: %w", f.name, err)
+				}
+				continue
+			}
+			if f.quoted {
+				if err := unmarshalQuoted(raw, fv); err != nil {
+					return fmt.Errorf("jsoncrypt: field %s: %w", f.name, err)
+				}
+				continue
+			}
+			if err := c.unmarshal(raw, fv); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		}
+		for i := 0; i < len(items) && i < v.Len(); i++ {
+			if err := c.unmarshal(items[i], v.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(m)))
+		}
+		for k, raw := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := c.unmarshal(raw, elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), elem)
+		}
+	}
+
+	return nil
+}
+
+// encrypt returns the json string holding the ciphertext of v
+func encrypt(aes types.AES, password string, v reflect.Value) ([]byte, error) {
+	if aes == nil {
+		return nil, errors.New("missing aes instance")
+	}
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return []byte("null"), nil
+		}
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.IsNil() {
+		return []byte("null"), nil
+	}
+
+	var plaintext string
+	if v.Kind() == reflect.String {
+		plaintext = v.String()
+	} else {
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		plaintext = string(b)
+	}
+
+	ciphertext, err := aes.EncryptText(plaintext, password)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ciphertext)
+}
+
+// decrypt stores the plaintext of the json string data in v
+func decrypt(aes types.AES, password string, data []byte, v reflect.Value) error {
+	if aes == nil {
+		return errors.New("missing aes instance")
+	}
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+
+	var ciphertext string
+	if err := json.Unmarshal(data, &ciphertext); err != nil {
+		return err
+	}
+	plaintext, err := aes.DecryptText(ciphertext, password)
+	if err != nil {
+		return err
+	}
+
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		v.SetString(plaintext)
+		return nil
+	}
+	return json.Unmarshal([]byte(plaintext), v.Addr().Interface())
+}
+
+// marshalQuoted encodes v inside a json string, like the ",string" option of encoding/json
+func marshalQuoted(v reflect.Value) ([]byte, error) {
+	b, err := json.Marshal(v.Interface())
+	if err != nil || string(b) == "null" {
+		return b, err
+	}
+	return json.Marshal(string(b))
+}
+
+// unmarshalQuoted is the reverse of marshalQuoted
+func unmarshalQuoted(data []byte, v reflect.Value) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(s), v.Addr().Interface())
+}
+
+// field describes a struct field as encoding/json sees it
+type field struct {
+	index     []int
+	name      string
+	tagged    bool // the name comes from the json tag
+	omitEmpty bool
+	quoted    bool // the ",string" option applies
+	encrypt   bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// fieldsOf returns the json fields of the struct type t in the order encoding/json writes them.
+// Fields of embedded structs are promoted following the same rules as encoding/json:
+// the shallowest field wins, then a tagged one, and names that are still ambiguous are dropped.
+func fieldsOf(t reflect.Type) []field {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]field)
+	}
+
+	type entry struct {
+		typ   reflect.Type
+		index []int
+	}
+	var current []entry
+	next := []entry{{typ: t}}
+	count, nextCount := map[reflect.Type]int{}, map[reflect.Type]int{}
+	visited := map[reflect.Type]bool{}
+	all := []field{}
+
+	// breadth first, one level of embedding at a time
+	for len(next) > 0 {
+		current, next = next, nil
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(append([]int{}, e.index...), i)
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					nextCount[ft]++
+					if nextCount[ft] == 1 {
+						next = append(next, entry{typ: ft, index: index})
+					}
+					continue
+				}
+
+				f := field{
+					index:     index,
+					name:      name,
+					tagged:    name != "",
+					omitEmpty: hasOption(opts, "omitempty"),
+					encrypt:   sf.Tag.Get(TagName) == "encrypt",
+				}
+				if f.name == "" {
+					f.name = sf.Name
+				}
+				if hasOption(opts, "string") {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.String,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+						reflect.Float32, reflect.Float64:
+						f.quoted = true
+					}
+				}
+				all = append(all, f)
+				if count[e.typ] > 1 {
+					// the struct is embedded more than once at this depth,
+					// so its fields are ambiguous and cancel each other out
+					all = append(all, f)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].name != all[j].name {
+			return all[i].name < all[j].name
+		}
+		if len(all[i].index) != len(all[j].index) {
+			return len(all[i].index) < len(all[j].index)
+		}
+		return all[i].tagged && !all[j].tagged
+	})
+
+	fields := []field{}
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].name == all[i].name {
+			j++
+		}
+		dominant := all[i]
+		if j-i == 1 || len(all[i+1].index) > len(dominant.index) || (dominant.tagged && !all[i+1].tagged) {
+			fields = append(fields, dominant)
+		}
+		i = j
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+func hasOption(opts, name string) bool {
+	return strings.Contains(","+opts+",", ","+name+",")
+}
+
+var (
+	marshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	taggedCache     sync.Map // map[reflect.Type]bool, only complete results are stored
+)
+
+// hasTagged reports whether values of type t may contain tagged fields that have to be walked.
+// Types with their own json methods are left to encoding/json,
+// while interfaces are always walked since their dynamic type is unknown.
+func hasTagged(t reflect.Type) (bool, error) {
+	if ok, found := taggedCache.Load(t); found {
+		return ok.(bool), nil
+	}
+	ok, err := hasTaggedVisit(t, map[reflect.Type]bool{})
+	if err != nil {
+		return false, err
+	}
+	taggedCache.Store(t, ok)
+	return ok, nil
+}
+
+// hasTaggedVisit implements hasTagged, a type that is already being visited
+// is reported as untagged so that recursive types terminate.
+func hasTaggedVisit(t reflect.Type, visiting map[reflect.Type]bool) (bool, error) {
+	if ok, found := taggedCache.Load(t); found {
+		return ok.(bool), nil
+	}
+	if visiting[t] {
+		return false, nil
+	}
+	visiting[t] = true
+
+	pt := reflect.PointerTo(t)
+	if t.Implements(marshalerType) || pt.Implements(marshalerType) ||
+		t.Implements(unmarshalerType) || pt.Implements(unmarshalerType) {
+		return false, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true, nil
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return hasTaggedVisit(t.Elem(), visiting)
+	case reflect.Map:
+		ok, err := hasTaggedVisit(t.Elem(), visiting)
+		if err == nil && ok && t.Key().Kind() != reflect.String {
+			return false, fmt.Errorf("jsoncrypt: unsupported map key type %s", t.Key())
+		}
+		return ok, err
+	case reflect.Struct:
+		for _, f := range fieldsOf(t) {
+			if f.encrypt {
+				return true, nil
+			}
+			ok, err := hasTaggedVisit(t.FieldByIndex(f.index).Type, visiting)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// lookup finds the value of a json field, preferring an exact match like encoding/json
+func lookup(m map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if raw, ok := m[name]; ok {
+		return raw, true
+	}
+	for k, raw := range m {
+		if strings.EqualFold(k, name) {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+// fieldByIndexAlloc returns the nested field of v, allocating nil embedded pointers
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
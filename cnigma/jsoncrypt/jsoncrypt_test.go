@@ -0,0 +1,220 @@
+package jsoncrypt_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/keng42/go/cnigma/aes"
+	"github.com/keng42/go/cnigma/aes/types"
+	"github.com/keng42/go/cnigma/jsoncrypt"
+	"github.com/stretchr/testify/require"
+)
+
+type Address struct {
+	City   string `json:"city"`
+	Street string `json:"street" cnigma:"encrypt"`
+}
+
+type Base struct {
+	ID int `json:"id"`
+}
+
+type User struct {
+	Base
+	Name     string             `json:"name"`
+	Email    string             `json:"email" cnigma:"encrypt"`
+	Age      int                `json:"age,omitempty" cnigma:"encrypt"`
+	Phone    *string            `json:"phone" cnigma:"encrypt"`
+	Tags     []string           `json:"tags" cnigma:"encrypt"`
+	Address  *Address           `json:"address,omitempty"`
+	Previous []Address          `json:"previous"`
+	Extra    map[string]Address `json:"extra,omitempty"`
+	Ignored  string             `json:"-"`
+}
+
+func newCodec(t *testing.T) *jsoncrypt.Codec {
+	a, err := aes.NewAES(types.ModeGCM, "", "my-password", types.Base64)
+	require.Nil(t, err)
+	return jsoncrypt.New(a)
+}
+
+func TestCodec(t *testing.T) {
+	c := newCodec(t)
+
+	phone := "+1 555 0100"
+	user := User{
+		Base:     Base{ID: 7},
+		Name:     "alice",
+		Email:    "alice@example.com",
+		Age:      42,
+		Phone:    &phone,
+		Tags:     []string{"admin"},
+		Address:  &Address{City: "Paris", Street: "1 rue de Rivoli"},
+		Previous: []Address{{City: "Lyon", Street: "2 rue de la Paix"}},
+		Extra:    map[string]Address{"work": {City: "Nice", Street: "3 rue Nice"}},
+		Ignored:  "ignored",
+	}
+
+	data, err := c.Marshal(user)
+	require.Nil(t, err)
+
+	var m map[string]interface{}
+	require.Nil(t, json.Unmarshal(data, &m))
+	require.Equal(t, float64(7), m["id"])
+	require.Equal(t, "alice", m["name"])
+	require.NotContains(t, string(data), "alice@example.com")
+	require.NotContains(t, string(data), "rue")
+	require.NotContains(t, string(data), "555")
+	require.NotContains(t, string(data), "ignored")
+	require.IsType(t, "", m["age"])
+	require.Equal(t, "Paris", m["address"].(map[string]interface{})["city"])
+
+	var decoded User
+	require.Nil(t, c.Unmarshal(data, &decoded))
+	user.Ignored = ""
+	require.Equal(t, user, decoded)
+
+	// zero values
+	data, err = c.Marshal(User{})
+	require.Nil(t, err)
+	m = nil
+	require.Nil(t, json.Unmarshal(data, &m))
+	require.NotContains(t, m, "age")
+	require.Nil(t, m["phone"])
+	require.Nil(t, m["tags"])
+
+	decoded = User{}
+	require.Nil(t, c.Unmarshal(data, &decoded))
+	require.Equal(t, User{}, decoded)
+
+	// slices and pointers of structs
+	list := []*Address{{City: "Paris", Street: "1 rue de Rivoli"}, nil}
+	data, err = c.Marshal(list)
+	require.Nil(t, err)
+	require.NotContains(t, string(data), "rue")
+
+	var decodedList []*Address
+	require.Nil(t, c.Unmarshal(data, &decodedList))
+	require.Equal(t, list, decodedList)
+
+	// wrong key
+	other, err := aes.NewAES(types.ModeGCM, "", "other-password", types.Base64)
+	require.Nil(t, err)
+	require.NotNil(t, jsoncrypt.New(other).Unmarshal(data, &decodedList))
+
+	require.NotNil(t, c.Unmarshal(data, decodedList))
+}
+
+func TestInterface(t *testing.T) {
+	c := newCodec(t)
+
+	data, err := c.Marshal(map[string]interface{}{"address": Address{City: "Paris", Street: "1 rue de Rivoli"}})
+	require.Nil(t, err)
+	require.NotContains(t, string(data), "rue")
+
+	var address Address
+	decoded := map[string]interface{}{}
+	require.Nil(t, json.Unmarshal(data, &decoded))
+	require.NotContains(t, decoded["address"].(map[string]interface{})["street"], "rue")
+
+	v := struct {
+		Address interface{} `json:"address"`
+	}{Address: &address}
+	require.Nil(t, c.Unmarshal(data, &v))
+	require.Equal(t, "1 rue de Rivoli", address.Street)
+}
+
+type Profile struct {
+	Email jsoncrypt.EncryptedString           `json:"email"`
+	Card  jsoncrypt.Encrypted[map[string]int] `json:"card"`
+}
+
+func TestEncrypted(t *testing.T) {
+	profile := Profile{
+		Email: "alice@example.com",
+		Card:  jsoncrypt.Encrypted[map[string]int]{Value: map[string]int{"cvc": 123}},
+	}
+
+	jsoncrypt.Register(nil)
+	_, err := json.Marshal(profile)
+	require.NotNil(t, err)
+
+	jsoncrypt.Register(newCodec(t))
+	data, err := json.Marshal(profile)
+	require.Nil(t, err)
+	require.NotContains(t, string(data), "alice")
+	require.NotContains(t, string(data), "cvc")
+
+	var decoded Profile
+	require.Nil(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, profile, decoded)
+}
+
+type Meta struct {
+	Created int64  `json:"created,string"`
+	Name    string `json:"name"`
+}
+
+type Account struct {
+	ID int64 `json:"id,string"`
+	Meta
+	Name   string `json:"name"`
+	Secret string `json:"secret" cnigma:"encrypt"`
+	Active *bool  `json:"active,string"`
+}
+
+func TestEncodingJSONCompatible(t *testing.T) {
+	c := newCodec(t)
+
+	active := true
+	account := Account{
+		ID:     42,
+		Meta:   Meta{Created: 1600000000, Name: "shadowed"},
+		Name:   "alice",
+		Secret: "s3cret",
+		Active: &active,
+	}
+
+	data, err := c.Marshal(account)
+	require.Nil(t, err)
+	expected, err := json.Marshal(account)
+	require.Nil(t, err)
+
+	// same keys in the same order, and the same values except for the encrypted one
+	got, want := objectOf(t, data), objectOf(t, expected)
+	require.Equal(t, []string{"id", "created", "name", "secret", "active"}, keysOf(t, data))
+	require.Equal(t, keysOf(t, expected), keysOf(t, data))
+	for k, v := range want {
+		if k != "secret" {
+			require.JSONEq(t, string(v), string(got[k]), k)
+		}
+	}
+	require.NotContains(t, string(data), "s3cret")
+
+	var decoded Account
+	require.Nil(t, c.Unmarshal(data, &decoded))
+	account.Meta.Name = ""
+	require.Equal(t, account, decoded)
+}
+
+func objectOf(t *testing.T, data []byte) map[string]json.RawMessage {
+	m := map[string]json.RawMessage{}
+	require.Nil(t, json.Unmarshal(data, &m))
+	return m
+}
+
+func keysOf(t *testing.T, data []byte) []string {
+	keys := []string{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	_, err := dec.Token()
+	require.Nil(t, err)
+	for dec.More() {
+		tok, err := dec.Token()
+		require.Nil(t, err)
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		require.Nil(t, dec.Decode(&skip))
+	}
+	return keys
+}
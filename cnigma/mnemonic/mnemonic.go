@@ -0,0 +1,129 @@
+// Package mnemonic encodes key material as BIP39-style mnemonic words for paper backups.
+//
+// Every word holds 11 bits, and the first size/32 bits of the sha256 of the key are
+// appended as a checksum, so a 256-bit key becomes 24 words.
+// Keys of 128 to 512 bits in steps of 32 bits are supported.
+package mnemonic
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+)
+
+//go:embed english.txt
+var english string
+
+var (
+	wordsOnce sync.Once
+	words     []string
+	indexes   map[string]int // words and their unique 4 letter prefixes
+)
+
+func wordlist() ([]string, map[string]int) {
+	wordsOnce.Do(func() {
+		words = strings.Fields(english)
+		indexes = make(map[string]int, 2*len(words))
+		for i, w := range words {
+			indexes[w] = i
+			if len(w) > 4 {
+				indexes[w[:4]] = i
+			}
+		}
+	})
+	return words, indexes
+}
+
+// Encode returns the mnemonic of the key material
+func Encode(entropy []byte) (string, error) {
+	size := len(entropy) * 8
+	if size < 128 || size > 512 || size%32 != 0 {
+		return "", errors.New("mnemonic requires 128 to 512 bits in steps of 32 bits")
+	}
+	words, _ := wordlist()
+
+	sum := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), sum[:]...)
+	n := (size + size/32) / 11
+
+	out := make([]string, n)
+	for i := range out {
+		out[i] = words[bitsAt(data, i*11)]
+	}
+	return strings.Join(out, " "), nil
+}
+
+// Decode returns the key material of the mnemonic after verifying its checksum.
+// Words are case-insensitive and may be abbreviated to their first 4 letters.
+func Decode(mnemonic string) ([]byte, error) {
+	_, indexes := wordlist()
+	fields := strings.Fields(strings.ToLower(mnemonic))
+	n := len(fields)
+	if n < 12 || n > 48 || n%3 != 0 {
+		return nil, errors.New("mnemonic requires 12 to 48 words in steps of 3 words")
+	}
+
+	data := make([]byte, (n*11+7)/8)
+	for i, w := range fields {
+		index, ok := indexes[w]
+		if !ok {
+			return nil, errors.New("unknown mnemonic word: " + w)
+		}
+		for b := 0; b < 11; b++ {
+			if index>>(10-b)&1 == 1 {
+				bit := i*11 + b
+				data[bit/8] |= 0x80 >> (bit % 8)
+			}
+		}
+	}
+
+	size := n * 11 * 32 / 33
+	entropy := data[:size/8]
+	sum := sha256.Sum256(entropy)
+	for b := 0; b < size/32; b++ {
+		bit := size + b
+		got := data[bit/8] >> (7 - bit%8) & 1
+		want := sum[b/8] >> (7 - b%8) & 1
+		if got != want {
+			return nil, errors.New("invalid mnemonic checksum")
+		}
+	}
+	return entropy, nil
+}
+
+// FromKey returns the mnemonic of a base64 encoded key such as one from aes.NewKey
+func FromKey(key string) (string, error) {
+	buf, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return "", err
+	}
+	return Encode(buf)
+}
+
+// ToKey returns the base64 encoded key of the mnemonic
+func ToKey(mnemonic string) (string, error) {
+	buf, err := Decode(mnemonic)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// IsMnemonic reports whether s looks like a mnemonic rather than a base64 key,
+// which never contains spaces.
+func IsMnemonic(s string) bool {
+	return len(strings.Fields(s)) >= 12
+}
+
+// bitsAt returns the 11 bits starting at the bit offset
+func bitsAt(data []byte, offset int) int {
+	v := 0
+	for b := 0; b < 11; b++ {
+		bit := offset + b
+		v = v<<1 | int(data[bit/8]>>(7-bit%8)&1)
+	}
+	return v
+}
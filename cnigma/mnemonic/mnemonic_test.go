@@ -0,0 +1,102 @@
+package mnemonic_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/keng42/go/cnigma/aes"
+	"github.com/keng42/go/cnigma/mnemonic"
+	"github.com/stretchr/testify/require"
+)
+
+// test vectors of BIP39
+func TestVectors(t *testing.T) {
+	vectors := []struct {
+		entropy, mnemonic string
+	}{
+		{"00000000000000000000000000000000", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+		{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", "legal winner thank year wave sausage worth useful legal winner thank yellow"},
+		{"0000000000000000000000000000000000000000000000000000000000000000", strings.Repeat("abandon ", 23) + "art"},
+		{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", strings.Repeat("zoo ", 23) + "vote"},
+		{"8080808080808080808080808080808080808080808080808080808080808080", "letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless"},
+	}
+
+	for _, v := range vectors {
+		entropy, err := hex.DecodeString(v.entropy)
+		require.Nil(t, err)
+
+		m, err := mnemonic.Encode(entropy)
+		require.Nil(t, err)
+		require.Equal(t, v.mnemonic, m)
+
+		decoded, err := mnemonic.Decode(m)
+		require.Nil(t, err)
+		require.Equal(t, entropy, decoded)
+	}
+}
+
+func TestKey(t *testing.T) {
+	for _, size := range []int{128, 192, 256, 384, 512} {
+		key, err := aes.NewKey(size)
+		require.Nil(t, err)
+
+		m, err := mnemonic.FromKey(key)
+		require.Nil(t, err)
+		require.Len(t, strings.Fields(m), (size+size/32)/11)
+		require.True(t, mnemonic.IsMnemonic(m))
+		require.False(t, mnemonic.IsMnemonic(key))
+
+		decoded, err := mnemonic.ToKey(m)
+		require.Nil(t, err)
+		require.Equal(t, key, decoded)
+
+		// case-insensitive, extra spaces and 4 letter abbreviations
+		var abbreviated []string
+		for _, w := range strings.Fields(m) {
+			if len(w) > 4 {
+				w = w[:4]
+			}
+			abbreviated = append(abbreviated, strings.ToUpper(w))
+		}
+		decoded, err = mnemonic.ToKey("  " + strings.Join(abbreviated, "  \n") + " ")
+		require.Nil(t, err)
+		require.Equal(t, key, decoded)
+	}
+
+	// a swapped word breaks the checksum
+	words := strings.Fields(strings.Repeat("abandon ", 23) + "art")
+	words[3] = "ability"
+	_, err := mnemonic.Decode(strings.Join(words, " "))
+	require.NotNil(t, err)
+
+	_, err = mnemonic.Decode(strings.Repeat("abandon ", 11) + "notaword")
+	require.NotNil(t, err)
+	_, err = mnemonic.Decode(strings.Repeat("abandon ", 13))
+	require.NotNil(t, err)
+	_, err = mnemonic.Encode(make([]byte, 18))
+	require.NotNil(t, err)
+}
+
+func TestNewAES(t *testing.T) {
+	key, err := aes.NewKey(256)
+	require.Nil(t, err)
+	m, err := mnemonic.FromKey(key)
+	require.Nil(t, err)
+
+	a, err := aes.NewGCM(key, "my-password", "")
+	require.Nil(t, err)
+	ciphertext, err := a.EncryptText("hello world @ 2020", "")
+	require.Nil(t, err)
+
+	// the key restored from a paper backup
+	b, err := aes.NewGCM(m, "my-password", "")
+	require.Nil(t, err)
+	plaintext, err := b.DecryptText(ciphertext, "")
+	require.Nil(t, err)
+	require.Equal(t, "hello world @ 2020", plaintext)
+
+	// a wrong checksum word
+	_, err = aes.NewGCM(strings.Repeat("abandon ", 23)+"artefact", "my-password", "")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,274 @@
+// Package password hashes and verifies passwords with argon2id, scrypt or pbkdf2-sha256.
+//
+// Hashes are stored as PHC strings, e.g. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>,
+// so the algorithm and parameters travel with the hash.
+package password
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/keng42/go/cnigma/aes/utils"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Algorithm is the password hashing algorithm, also the identifier in the PHC string
+type Algorithm string
+
+// Supported algorithms
+const (
+	Argon2id     Algorithm = "argon2id"
+	Scrypt       Algorithm = "scrypt"
+	PBKDF2SHA256 Algorithm = "pbkdf2-sha256"
+)
+
+// Limits bounds the parameters Verify accepts from a PHC string,
+// so that a hostile hash can't make it use unbounded memory or time.
+type Limits struct {
+	Memory     uint32 // argon2id memory and scrypt's 128*N*r bytes, in KiB
+	Time       uint32 // argon2id passes
+	Threads    uint8  // argon2id threads
+	ScryptLogN int    // log2 of scrypt's N
+	ScryptR    int
+	ScryptP    int
+	Iterations int // pbkdf2-sha256 iterations
+	KeySize    int // in bytes
+}
+
+// DefaultLimits are the limits of Verify, well above the parameters recommended by New
+var DefaultLimits = Limits{
+	Memory:     1 << 20, // 1 GiB
+	Time:       16,
+	Threads:    16,
+	ScryptLogN: 20,
+	ScryptR:    32,
+	ScryptP:    16,
+	Iterations: 2000000,
+	KeySize:    64,
+}
+
+// check returns an error if the parameters of h exceed the limits
+func (l Limits) check(h *Hasher) error {
+	ok := h.KeySize <= l.KeySize
+	switch h.Algorithm {
+	case Argon2id:
+		ok = ok && h.Memory <= l.Memory && h.Time <= l.Time && h.Threads <= l.Threads
+	case Scrypt:
+		ok = ok && bits.Len(uint(h.N))-1 <= l.ScryptLogN && h.R <= l.ScryptR && h.P <= l.ScryptP &&
+			uint64(h.N)*uint64(h.R)/8 <= uint64(l.Memory)
+	case PBKDF2SHA256:
+		ok = ok && h.Iterations <= l.Iterations
+	}
+	if !ok {
+		return errors.New("phc parameters exceed the limits")
+	}
+	return nil
+}
+
+// Hasher holds the algorithm and its cost parameters
+type Hasher struct {
+	Algorithm Algorithm
+
+	// argon2id
+	Time    uint32 // number of passes
+	Memory  uint32 // memory in KiB
+	Threads uint8
+
+	// scrypt
+	N int // cpu/memory cost, a power of two
+	R int // block size
+	P int // parallelization
+
+	// pbkdf2-sha256
+	Iterations int
+
+	SaltSize int // in bytes
+	KeySize  int // in bytes
+}
+
+// New returns a Hasher with the recommended parameters of the algorithm,
+// argon2id is used if the algorithm is empty.
+func New(algorithm Algorithm) (*Hasher, error) {
+	h := &Hasher{Algorithm: algorithm, SaltSize: 16, KeySize: 32}
+	switch algorithm {
+	case "", Argon2id:
+		h.Algorithm = Argon2id
+		h.Time, h.Memory, h.Threads = 3, 64*1024, 4
+	case Scrypt:
+		h.N, h.R, h.P = 1<<15, 8, 1
+	case PBKDF2SHA256:
+		h.Iterations = 600000
+	default:
+		return nil, errors.New("only support argon2id, scrypt and pbkdf2-sha256")
+	}
+	return h, nil
+}
+
+// Hash hashes the password with a random salt and returns the PHC string
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.SaltSize < 8 || h.KeySize < 16 {
+		return "", errors.New("salt requires at least 8 bytes and key at least 16 bytes")
+	}
+	salt, err := utils.RandomBytes(h.SaltSize)
+	if err != nil {
+		return "", err
+	}
+	key, err := h.derive(password, salt)
+	if err != nil {
+		return "", err
+	}
+	return h.format(salt, key), nil
+}
+
+// Verify reports whether the password matches the PHC string hash.
+// The error is only non-nil if the hash is malformed or its parameters exceed DefaultLimits.
+func Verify(password, hash string) (bool, error) {
+	return VerifyWithLimits(password, hash, DefaultLimits)
+}
+
+// VerifyWithLimits is Verify with other limits, for hashes created with parameters beyond DefaultLimits
+func VerifyWithLimits(password, hash string, limits Limits) (bool, error) {
+	h, salt, key, err := parse(hash)
+	if err != nil {
+		return false, err
+	}
+	h.SaltSize, h.KeySize = len(salt), len(key)
+	if err := limits.check(h); err != nil {
+		return false, err
+	}
+	derived, err := h.derive(password, salt)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(key, derived) == 1, nil
+}
+
+// NeedsRehash reports whether the hash was created with another algorithm or other parameters,
+// so it should be replaced with a new hash after the next successful login.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	other, salt, key, err := parse(hash)
+	if err != nil {
+		return true
+	}
+	other.SaltSize, other.KeySize = len(salt), len(key)
+	return other.normalized() != h.normalized()
+}
+
+// normalized returns a copy with only the parameters used by the algorithm
+func (h *Hasher) normalized() Hasher {
+	n := Hasher{Algorithm: h.Algorithm, SaltSize: h.SaltSize, KeySize: h.KeySize}
+	switch h.Algorithm {
+	case Argon2id:
+		n.Time, n.Memory, n.Threads = h.Time, h.Memory, h.Threads
+	case Scrypt:
+		n.N, n.R, n.P = h.N, h.R, h.P
+	case PBKDF2SHA256:
+		n.Iterations = h.Iterations
+	}
+	return n
+}
+
+func (h *Hasher) derive(password string, salt []byte) ([]byte, error) {
+	switch h.Algorithm {
+	case Argon2id:
+		if h.Time < 1 || h.Threads < 1 || h.Memory < 8*uint32(h.Threads) {
+			return nil, errors.New("invalid argon2id parameters")
+		}
+		return argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, uint32(h.KeySize)), nil
+	case Scrypt:
+		return scrypt.Key([]byte(password), salt, h.N, h.R, h.P, h.KeySize)
+	case PBKDF2SHA256:
+		if h.Iterations < 1 {
+			return nil, errors.New("invalid pbkdf2 iterations")
+		}
+		return pbkdf2.Key([]byte(password), salt, h.Iterations, h.KeySize, sha256.New), nil
+	}
+	return nil, errors.New("only support argon2id, scrypt and pbkdf2-sha256")
+}
+
+func (h *Hasher) format(salt, key []byte) string {
+	var params string
+	switch h.Algorithm {
+	case Argon2id:
+		params = fmt.Sprintf("v=%d$m=%d,t=%d,p=%d", argon2.Version, h.Memory, h.Time, h.Threads)
+	case Scrypt:
+		params = fmt.Sprintf("ln=%d,r=%d,p=%d", bits.Len(uint(h.N))-1, h.R, h.P)
+	case PBKDF2SHA256:
+		params = fmt.Sprintf("i=%d", h.Iterations)
+	}
+	return fmt.Sprintf("$%s$%s$%s$%s", h.Algorithm, params,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// parse parses a PHC string into a Hasher without salt and key sizes
+func parse(hash string) (*Hasher, []byte, []byte, error) {
+	fields := strings.Split(hash, "$")
+	if len(fields) < 5 || fields[0] != "" {
+		return nil, nil, nil, errors.New("invalid phc string")
+	}
+
+	h := &Hasher{Algorithm: Algorithm(fields[1])}
+	rest := fields[2:]
+	if h.Algorithm == Argon2id {
+		if len(rest) != 4 || rest[0] != fmt.Sprintf("v=%d", argon2.Version) {
+			return nil, nil, nil, errors.New("unsupported argon2id version")
+		}
+		rest = rest[1:]
+	}
+	if len(rest) != 3 {
+		return nil, nil, nil, errors.New("invalid phc string")
+	}
+
+	params := map[string]uint64{}
+	for _, kv := range strings.Split(rest[0], ",") {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 {
+			return nil, nil, nil, errors.New("invalid phc parameters")
+		}
+		// every parameter fits an int32, Limits bounds them further before any work is done
+		v, err := strconv.ParseUint(kv[i+1:], 10, 31)
+		if err != nil {
+			return nil, nil, nil, errors.New("invalid phc parameters")
+		}
+		params[kv[:i]] = v
+	}
+
+	switch h.Algorithm {
+	case Argon2id:
+		if params["p"] > 255 {
+			return nil, nil, nil, errors.New("invalid phc parameters")
+		}
+		h.Memory, h.Time, h.Threads = uint32(params["m"]), uint32(params["t"]), uint8(params["p"])
+	case Scrypt:
+		if params["ln"] < 1 || params["ln"] > 30 {
+			return nil, nil, nil, errors.New("invalid phc parameters")
+		}
+		h.N, h.R, h.P = 1<<params["ln"], int(params["r"]), int(params["p"])
+	case PBKDF2SHA256:
+		h.Iterations = int(params["i"])
+	default:
+		return nil, nil, nil, errors.New("unsupported algorithm")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(rest[1])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(rest[2])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(salt) == 0 || len(key) == 0 {
+		return nil, nil, nil, errors.New("invalid phc string")
+	}
+
+	return h, salt, key, nil
+}
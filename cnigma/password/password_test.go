@@ -0,0 +1,143 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keng42/go/cnigma/password"
+	"github.com/stretchr/testify/require"
+)
+
+// cheap parameters so the tests run fast
+func cheap(t *testing.T, algorithm password.Algorithm) *password.Hasher {
+	h, err := password.New(algorithm)
+	require.Nil(t, err)
+	h.Time, h.Memory, h.Threads = 1, 1024, 1
+	h.N = 1 << 10
+	h.Iterations = 1000
+	return h
+}
+
+func TestHashVerify(t *testing.T) {
+	for _, algorithm := range []password.Algorithm{password.Argon2id, password.Scrypt, password.PBKDF2SHA256} {
+		h := cheap(t, algorithm)
+
+		hash, err := h.Hash("correct horse battery staple")
+		require.Nil(t, err)
+		require.True(t, strings.HasPrefix(hash, "$"+string(algorithm)+"$"))
+
+		ok, err := password.Verify("correct horse battery staple", hash)
+		require.Nil(t, err)
+		require.True(t, ok)
+
+		ok, err = password.Verify("Correct horse battery staple", hash)
+		require.Nil(t, err)
+		require.False(t, ok)
+
+		// random salts give different hashes of the same password
+		other, err := h.Hash("correct horse battery staple")
+		require.Nil(t, err)
+		require.NotEqual(t, hash, other)
+
+		require.False(t, h.NeedsRehash(hash))
+	}
+}
+
+func TestKnownHashes(t *testing.T) {
+	// RFC 7914 scrypt test vector
+	ok, err := password.Verify("password", "$scrypt$ln=10,r=8,p=16$TmFDbA$/bq+HJ00cgB4VucZDQHp/nxq18vII3gw53N2Y0s3MWIurzDZLiKjiG/xCSedmDDaxyevuUqD7m2DYMvfoswGQA")
+	require.Nil(t, err)
+	require.True(t, ok)
+
+	// RFC 7914 pbkdf2-hmac-sha256 test vector
+	ok, err = password.Verify("password", "$pbkdf2-sha256$i=1$c2FsdA$Eg+2z/z4syxD5yJSVsT4N6hlSMkszDVICAWYfLcL4Xs")
+	require.Nil(t, err)
+	require.True(t, ok)
+}
+
+func TestNeedsRehash(t *testing.T) {
+	h := cheap(t, password.Argon2id)
+	hash, err := h.Hash("hello")
+	require.Nil(t, err)
+	require.False(t, h.NeedsRehash(hash))
+
+	stronger := cheap(t, password.Argon2id)
+	stronger.Time = 2
+	require.True(t, stronger.NeedsRehash(hash))
+
+	longer := cheap(t, password.Argon2id)
+	longer.KeySize = 64
+	require.True(t, longer.NeedsRehash(hash))
+
+	migrated := cheap(t, password.Scrypt)
+	require.True(t, migrated.NeedsRehash(hash))
+
+	require.True(t, h.NeedsRehash("not a hash"))
+}
+
+func TestInvalid(t *testing.T) {
+	_, err := password.New("md5")
+	require.NotNil(t, err)
+
+	hashes := []string{
+		"",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=19$m=1024,t=0,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$scrypt$ln=abc,r=8,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$pbkdf2-sha256$i=1000$$aGFzaGhhc2hoYXNoaGFzaA",
+		"$bcrypt$i=1000$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+
+		"$argon2id$v=19$m=4294967296,t=1,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$scrypt$ln=62,r=8,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$scrypt$ln=10,r=2,p=9223372036854775808$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+	}
+	for _, hash := range hashes {
+		ok, err := password.Verify("hello", hash)
+		require.NotNil(t, err, hash)
+		require.False(t, ok)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	// hashes that would make Verify use gigabytes of memory or minutes of cpu
+	hostile := []string{
+		"$argon2id$v=19$m=4194304,t=1024,p=255$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=19$m=1048577,t=1,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=19$m=65536,t=17,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$argon2id$v=19$m=65536,t=1,p=17$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$scrypt$ln=24,r=8,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$scrypt$ln=21,r=1,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$scrypt$ln=20,r=16,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$scrypt$ln=10,r=1,p=536870911$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$scrypt$ln=10,r=33,p=1$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$pbkdf2-sha256$i=10000000$c2FsdHNhbHQ$aGFzaGhhc2hoYXNoaGFzaA",
+		"$pbkdf2-sha256$i=2000000$c2FsdHNhbHQ$" + strings.Repeat("A", 1366),
+	}
+	start := time.Now()
+	for _, hash := range hostile {
+		ok, err := password.Verify("hello", hash)
+		require.NotNil(t, err, hash)
+		require.False(t, ok)
+	}
+	// rejected before any work is done
+	require.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// callers can lower or raise the limits
+	h := cheap(t, password.PBKDF2SHA256)
+	hash, err := h.Hash("hello")
+	require.Nil(t, err)
+	strict := password.DefaultLimits
+	strict.Iterations = 100
+	_, err = password.VerifyWithLimits("hello", hash, strict)
+	require.NotNil(t, err)
+
+	long := "$pbkdf2-sha256$i=1$c2FsdHNhbHQ$" + strings.Repeat("A", 200)
+	_, err = password.Verify("hello", long)
+	require.NotNil(t, err)
+	relaxed := password.DefaultLimits
+	relaxed.KeySize = 256
+	ok, err := password.VerifyWithLimits("hello", long, relaxed)
+	require.Nil(t, err)
+	require.False(t, ok)
+}
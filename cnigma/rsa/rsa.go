@@ -11,17 +11,22 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
-	"encoding/base64"
-	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/keng42/go/cnigma/encoding"
 	"github.com/keng42/go/cnigma/rsa/types"
 )
 
+// Modes recorded in the headers of armored output
+const (
+	ModeSign    = "rsa-pkcs1v15-sha256"
+	ModeEncrypt = "rsa-oaep-sha256"
+)
+
 // RSA sturct stores private key and some configs
 type RSA struct {
 	PrivateKey *rsa.PrivateKey
@@ -34,6 +39,9 @@ func NewRSA(encoding types.EncodingType) (*RSA, error) {
 	if encoding == "" {
 		encoding = types.Base64
 	}
+	if !encoding.Valid() {
+		return nil, errors.New("unsupported encoding")
+	}
 	return &RSA{Encoding: encoding}, nil
 }
 
@@ -51,20 +59,17 @@ func (r *RSA) Sign(msg string) (string, error) {
 		return "", err
 	}
 
-	return r.encode(signature), err
+	return r.encode(signature, encoding.ArmorSignature, ModeSign)
 }
 
 // Verify message with public key
 func (r *RSA) Verify(msg, sig string) (bool, error) {
-	pub := r.PublicKey
-	if pub == nil && r.PrivateKey != nil {
-		pub = &r.PrivateKey.PublicKey
-	}
+	pub := r.publicKey()
 	if pub == nil {
 		return false, errors.New("missing public key")
 	}
 
-	signature, err := r.decode(sig)
+	signature, err := r.decode(sig, encoding.ArmorSignature)
 	if err != nil {
 		return false, err
 	}
@@ -76,10 +81,7 @@ func (r *RSA) Verify(msg, sig string) (bool, error) {
 
 // Encrypt text with public key
 func (r *RSA) Encrypt(plaintext string) (string, error) {
-	pub := r.PublicKey
-	if pub == nil && r.PrivateKey != nil {
-		pub = &r.PrivateKey.PublicKey
-	}
+	pub := r.publicKey()
 	if pub == nil {
 		return "", errors.New("missing public key")
 	}
@@ -89,7 +91,7 @@ func (r *RSA) Encrypt(plaintext string) (string, error) {
 		return "", err
 	}
 
-	return r.encode(ciphertext), nil
+	return r.encode(ciphertext, encoding.ArmorMessage, ModeEncrypt)
 }
 
 // Decrypt text with private key
@@ -98,7 +100,7 @@ func (r *RSA) Decrypt(ciphertext string) (string, error) {
 		return "", errors.New("missing private key")
 	}
 
-	cipherBuf, err := r.decode(ciphertext)
+	cipherBuf, err := r.decode(ciphertext, encoding.ArmorMessage)
 	if err != nil {
 		return "", err
 	}
@@ -111,20 +113,76 @@ func (r *RSA) Decrypt(ciphertext string) (string, error) {
 	return string(plaintext), nil
 }
 
-// encode bytes to string
-func (r *RSA) encode(buf []byte) string {
-	if r.Encoding == types.Base64 {
-		return base64.StdEncoding.EncodeToString(buf)
+// encode bytes to string.
+// Armored output is a block of the specified type recording the mode and the key id.
+func (r *RSA) encode(buf []byte, blockType, mode string) (string, error) {
+	if r.Encoding != types.Armor {
+		return r.Encoding.EncodeToString(buf)
+	}
+
+	block := &encoding.Block{
+		Type: blockType,
+		Headers: map[string]string{
+			encoding.HeaderMode:  mode,
+			encoding.HeaderKeyID: r.keyID(),
+		},
+		Bytes: buf,
+	}
+	return encoding.EncodeBlock(block, types.Base64)
+}
+
+// decode string to bytes.
+// Armored text of the specified block type is accepted whatever the encoding is.
+// In auto mode the encoding is detected by the length of the decoded bytes,
+// since both signatures and ciphertexts are exactly as long as the modulus.
+func (r *RSA) decode(text, blockType string) ([]byte, error) {
+	if encoding.IsArmored(text) {
+		block, err := encoding.DecodeBlock(text)
+		if err != nil {
+			return nil, err
+		}
+		if block.Type != blockType {
+			return nil, errors.New("unexpected armored block type " + block.Type)
+		}
+		if id := block.Headers[encoding.HeaderKeyID]; id != "" && id != r.keyID() {
+			return nil, errors.New("armored block was created with another key")
+		}
+		return block.Bytes, nil
+	}
+
+	if r.Encoding != types.Auto {
+		return r.Encoding.DecodeString(text)
+	}
+
+	size := 0
+	if pub := r.publicKey(); pub != nil {
+		size = pub.Size()
+	}
+	buf, _, err := encoding.DecodeAny(text, func(b []byte) bool {
+		return size == 0 || len(b) == size
+	})
+	return buf, err
+}
+
+// publicKey returns the public key, falling back to the public part of the private key
+func (r *RSA) publicKey() *rsa.PublicKey {
+	if r.PublicKey == nil && r.PrivateKey != nil {
+		return &r.PrivateKey.PublicKey
 	}
-	return hex.EncodeToString(buf)
+	return r.PublicKey
 }
 
-// decode string to bytes
-func (r *RSA) decode(text string) ([]byte, error) {
-	if r.Encoding == types.Base64 {
-		return base64.StdEncoding.DecodeString(text)
+// keyID returns the id of the public key used in armor headers
+func (r *RSA) keyID() string {
+	pub := r.publicKey()
+	if pub == nil {
+		return ""
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return ""
 	}
-	return hex.DecodeString(text)
+	return encoding.KeyID(der)
 }
 
 // LoadPrivateKey load private key from file
@@ -163,11 +221,26 @@ func LoadPublicKey(filepath string) (*rsa.PublicKey, error) {
 	}
 	t := string(buf)
 
-	if strings.HasPrefix(t, "-----BEGIN PUBLIC KEY-----") {
+	// PKCS1
+	if strings.HasPrefix(t, "-----BEGIN RSA PUBLIC KEY-----") {
 		block, _ := pem.Decode(buf)
 		return x509.ParsePKCS1PublicKey(block.Bytes)
 	}
 
+	// PKIX, older files of this package hold PKCS1 bytes under this header
+	if strings.HasPrefix(t, "-----BEGIN PUBLIC KEY-----") {
+		block, _ := pem.Decode(buf)
+		parseResult, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return x509.ParsePKCS1PublicKey(block.Bytes)
+		}
+		key, ok := parseResult.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("public key is not a rsa key")
+		}
+		return key, nil
+	}
+
 	return nil, errors.New("invalid public key file")
 }
 
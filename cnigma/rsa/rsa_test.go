@@ -1,8 +1,13 @@
 package rsa_test
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/keng42/go/cnigma/rsa"
@@ -16,6 +21,29 @@ func TestLoadPrivateKey(t *testing.T) {
 	require.NotNil(t, priv)
 }
 
+func TestLoadPublicKey(t *testing.T) {
+	priv, err := rsa.LoadPrivateKey("../testdata/rsa-private-pkcs8.key")
+	require.Nil(t, err)
+
+	pkix, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.Nil(t, err)
+	pkcs1 := x509.MarshalPKCS1PublicKey(&priv.PublicKey)
+
+	blocks := []*pem.Block{
+		{Type: "PUBLIC KEY", Bytes: pkix},
+		{Type: "PUBLIC KEY", Bytes: pkcs1},
+		{Type: "RSA PUBLIC KEY", Bytes: pkcs1},
+	}
+	for i, block := range blocks {
+		path := filepath.Join(t.TempDir(), fmt.Sprintf("public-%d.pem", i))
+		require.Nil(t, os.WriteFile(path, pem.EncodeToMemory(block), 0644))
+
+		pub, err := rsa.LoadPublicKey(path)
+		require.Nil(t, err)
+		require.True(t, priv.PublicKey.Equal(pub))
+	}
+}
+
 func TestNewRSA(t *testing.T) {
 	priv, err := rsa.LoadPrivateKey("../testdata/rsa-private-pkcs8.key")
 	require.Nil(t, err)
@@ -91,3 +119,73 @@ func ExampleNewRSA() {
 	}
 	fmt.Println("plaintext", plaintext)
 }
+
+func TestEncodings(t *testing.T) {
+	priv, err := rsa.LoadPrivateKey("../testdata/rsa-private-pkcs8.key")
+	require.Nil(t, err)
+
+	auto, err := rsa.NewRSA(types.Auto)
+	require.Nil(t, err)
+	auto.PrivateKey = priv
+
+	msg := "hello world @ 2020"
+	for _, encoding := range []types.EncodingType{types.Base64URLRaw, types.Base32, types.Z85} {
+		r, err := rsa.NewRSA(encoding)
+		require.Nil(t, err)
+		r.PrivateKey = priv
+
+		sig, err := r.Sign(msg)
+		require.Nil(t, err)
+
+		verified, err := auto.Verify(msg, sig)
+		require.Nil(t, err)
+		require.True(t, verified)
+
+		ciphertext, err := r.Encrypt(msg)
+		require.Nil(t, err)
+
+		plaintext, err := auto.Decrypt(ciphertext)
+		require.Nil(t, err)
+		require.Equal(t, msg, plaintext)
+	}
+
+	_, err = rsa.NewRSA("base58")
+	require.NotNil(t, err)
+}
+
+func TestArmor(t *testing.T) {
+	priv, err := rsa.LoadPrivateKey("../testdata/rsa-private-pkcs8.key")
+	require.Nil(t, err)
+
+	r, err := rsa.NewRSA(types.Armor)
+	require.Nil(t, err)
+	r.PrivateKey = priv
+
+	msg := "hello world @ 2020"
+
+	sig, err := r.Sign(msg)
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(sig, "-----BEGIN CNIGMA SIGNATURE-----\nMode: "+rsa.ModeSign+"\n"))
+
+	ciphertext, err := r.Encrypt(msg)
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(ciphertext, "-----BEGIN CNIGMA MESSAGE-----\nMode: "+rsa.ModeEncrypt+"\n"))
+
+	// any encoding accepts armored text
+	hex, err := rsa.NewRSA(types.Hex)
+	require.Nil(t, err)
+	hex.PublicKey = &priv.PublicKey
+
+	verified, err := hex.Verify(msg, sig)
+	require.Nil(t, err)
+	require.True(t, verified)
+
+	hex.PrivateKey = priv
+	plaintext, err := hex.Decrypt(ciphertext)
+	require.Nil(t, err)
+	require.Equal(t, msg, plaintext)
+
+	// a message is not a signature
+	_, err = hex.Verify(msg, ciphertext)
+	require.NotNil(t, err)
+}
@@ -1,11 +1,22 @@
 package types
 
-type EncodingType string
+import "github.com/keng42/go/cnigma/encoding"
+
+// EncodingType is the text encoding of signatures and ciphertexts, see package encoding
+type EncodingType = encoding.Type
 
 const (
-	Base64     EncodingType = "base64"
-	Hex        EncodingType = "hex"
-	DefaultKey string       = "7At16p/dyonmDW3ll9Pl1bmCsWEACxaIzLmyC0ZWGaE="
+	Base64       EncodingType = encoding.Base64
+	Base64URL    EncodingType = encoding.Base64URL
+	Base64Raw    EncodingType = encoding.Base64Raw
+	Base64URLRaw EncodingType = encoding.Base64URLRaw
+	Base32       EncodingType = encoding.Base32
+	Hex          EncodingType = encoding.Hex
+	Ascii85      EncodingType = encoding.Ascii85
+	Z85          EncodingType = encoding.Z85
+	Auto         EncodingType = encoding.Auto
+	Armor        EncodingType = encoding.Armor
+	DefaultKey   string       = "7At16p/dyonmDW3ll9Pl1bmCsWEACxaIzLmyC0ZWGaE="
 )
 
 const (
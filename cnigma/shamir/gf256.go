@@ -0,0 +1,49 @@
+package shamir
+
+// Arithmetic in GF(2^8) with the AES reducing polynomial x^8 + x^4 + x^3 + x + 1.
+// Multiplication avoids lookup tables so the timing doesn't depend on secret bytes.
+
+func gfAdd(a, b byte) byte {
+	return a ^ b
+}
+
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		// mask is 0xff when the lowest bit of b is set
+		mask := -(b & 1)
+		p ^= a & mask
+		carry := -(a >> 7)
+		a = (a << 1) ^ (0x1b & carry)
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv returns a^254 which is the inverse of a, 0 has no inverse and returns 0
+func gfInv(a byte) byte {
+	b := gfMul(a, a)   // a^2
+	c := gfMul(a, b)   // a^3
+	b = gfMul(c, c)    // a^6
+	b = gfMul(b, b)    // a^12
+	c = gfMul(b, c)    // a^15
+	b = gfMul(b, b)    // a^24
+	b = gfMul(b, b)    // a^48
+	b = gfMul(b, c)    // a^63
+	b = gfMul(b, b)    // a^126
+	b = gfMul(a, b)    // a^127
+	return gfMul(b, b) // a^254
+}
+
+func gfDiv(a, b byte) byte {
+	return gfMul(a, gfInv(b))
+}
+
+// evaluate returns the value of the polynomial at x, coefficients[0] is the constant term
+func evaluate(coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = gfAdd(gfMul(y, x), coefficients[i])
+	}
+	return y
+}
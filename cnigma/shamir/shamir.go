@@ -0,0 +1,227 @@
+// Package shamir implements Shamir's secret sharing over GF(256).
+//
+// A secret is split into n shares and any k of them recover it,
+// while fewer than k shares reveal nothing about the secret.
+// The shares of one split carry the same random set id, and a checksum of the secret
+// is shared along with it, so shares of other secrets or corrupted shares are detected.
+package shamir
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SharePrefix is the prefix of the text form of a share
+const SharePrefix = "cnigma-share"
+
+// checksumSize is the size of the checksum of the secret that is split along with it
+const checksumSize = 4
+
+// Share is one part of a split secret
+type Share struct {
+	ID        uint32 // random identifier shared by all the shares of a split
+	Index     byte   // x coordinate, from 1 to 255
+	Threshold byte   // number of shares required to recover the secret
+	Data      []byte // y coordinates, one byte per byte of the secret and its checksum
+}
+
+// Split splits the secret into n shares, any k of them are enough to recover the secret.
+func Split(secret []byte, n, k int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+	if k < 2 || k > n || n > 255 {
+		return nil, errors.New("shares require 2 <= k <= n <= 255")
+	}
+
+	var id [4]byte
+	if _, err := io.ReadFull(rand.Reader, id[:]); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(secret)
+	secret = append(append([]byte{}, secret...), sum[:checksumSize]...)
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{
+			ID:        binary.BigEndian.Uint32(id[:]),
+			Index:     byte(i + 1),
+			Threshold: byte(k),
+			Data:      make([]byte, len(secret)),
+		}
+	}
+
+	coefficients := make([]byte, k)
+	for i, b := range secret {
+		coefficients[0] = b
+		if _, err := io.ReadFull(rand.Reader, coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for j := range shares {
+			shares[j].Data[i] = evaluate(coefficients, shares[j].Index)
+		}
+	}
+	for i := range coefficients {
+		coefficients[i] = 0
+	}
+	for i := range secret {
+		secret[i] = 0
+	}
+
+	return shares, nil
+}
+
+// Combine recovers the secret from at least threshold shares.
+// Shares beyond the threshold must lie on the same polynomial, and the checksum of the secret must match,
+// otherwise an error is returned instead of a wrong secret.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares")
+	}
+
+	id := shares[0].ID
+	threshold := shares[0].Threshold
+	size := len(shares[0].Data)
+	seen := map[byte]bool{}
+	for _, s := range shares {
+		if s.Index == 0 {
+			return nil, errors.New("invalid share index")
+		}
+		if s.ID != id || s.Threshold != threshold || len(s.Data) != size {
+			return nil, errors.New("shares are from different secrets")
+		}
+		if seen[s.Index] {
+			return nil, errors.New("duplicate share index")
+		}
+		seen[s.Index] = true
+	}
+	if size <= checksumSize {
+		return nil, errors.New("invalid share data")
+	}
+	if len(shares) < int(threshold) {
+		return nil, fmt.Errorf("requires %d shares but got %d", threshold, len(shares))
+	}
+
+	// exactly threshold shares define the polynomial, the others are checked against it
+	for _, extra := range shares[threshold:] {
+		if subtle.ConstantTimeCompare(interpolate(shares[:threshold], extra.Index), extra.Data) != 1 {
+			return nil, fmt.Errorf("share %d doesn't match the other shares", extra.Index)
+		}
+	}
+
+	secret := interpolate(shares[:threshold], 0)
+	n := size - checksumSize
+	sum := sha256.Sum256(secret[:n])
+	if subtle.ConstantTimeCompare(sum[:checksumSize], secret[n:]) != 1 {
+		return nil, errors.New("shares are corrupted or from different secrets")
+	}
+	return secret[:n], nil
+}
+
+// interpolate returns the values at x of the polynomials going through the shares
+func interpolate(shares []Share, x byte) []byte {
+	out := make([]byte, len(shares[0].Data))
+	for i, si := range shares {
+		// lagrange basis polynomial at x
+		basis := byte(1)
+		for j, sj := range shares {
+			if i == j {
+				continue
+			}
+			basis = gfMul(basis, gfDiv(gfAdd(x, sj.Index), gfAdd(sj.Index, si.Index)))
+		}
+		for b := range out {
+			out[b] = gfAdd(out[b], gfMul(si.Data[b], basis))
+		}
+	}
+	return out
+}
+
+// String returns the text form of the share,
+// it's cnigma-share-<threshold>-<hex id>-<index>-<base64url data>-<crc32 checksum>.
+func (s Share) String() string {
+	body := fmt.Sprintf("%s-%d-%08x-%d-%s", SharePrefix, s.Threshold, s.ID, s.Index, base64.RawURLEncoding.EncodeToString(s.Data))
+	return fmt.Sprintf("%s-%08x", body, crc32.ChecksumIEEE([]byte(body)))
+}
+
+// ParseShare parses the text form of a share and verifies its checksum
+func ParseShare(text string) (Share, error) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, SharePrefix+"-") {
+		return Share{}, errors.New("invalid share prefix")
+	}
+
+	i := strings.LastIndexByte(text, '-')
+	body, checksum := text[:i], text[i+1:]
+	sum, err := strconv.ParseUint(checksum, 16, 32)
+	if err != nil || len(checksum) != 8 || uint32(sum) != crc32.ChecksumIEEE([]byte(body)) {
+		return Share{}, errors.New("invalid share checksum")
+	}
+
+	// the base64url data may contain '-' so it's split at most into 4 fields
+	fields := strings.SplitN(strings.TrimPrefix(body, SharePrefix+"-"), "-", 4)
+	if len(fields) != 4 {
+		return Share{}, errors.New("invalid share format")
+	}
+	threshold, err := strconv.ParseUint(fields[0], 10, 8)
+	if err != nil || threshold < 2 {
+		return Share{}, errors.New("invalid share threshold")
+	}
+	id, err := strconv.ParseUint(fields[1], 16, 32)
+	if err != nil || len(fields[1]) != 8 {
+		return Share{}, errors.New("invalid share id")
+	}
+	index, err := strconv.ParseUint(fields[2], 10, 8)
+	if err != nil || index == 0 {
+		return Share{}, errors.New("invalid share index")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(fields[3])
+	if err != nil || len(data) <= checksumSize {
+		return Share{}, errors.New("invalid share data")
+	}
+
+	return Share{ID: uint32(id), Index: byte(index), Threshold: byte(threshold), Data: data}, nil
+}
+
+// SplitKey splits a base64 encoded key, such as one from aes.NewKey, into text shares
+func SplitKey(key string, n, k int) ([]string, error) {
+	buf, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := Split(buf, n, k)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, len(shares))
+	for i, s := range shares {
+		texts[i] = s.String()
+	}
+	return texts, nil
+}
+
+// CombineKey recovers a base64 encoded key from text shares
+func CombineKey(texts []string) (string, error) {
+	shares := make([]Share, len(texts))
+	for i, text := range texts {
+		s, err := ParseShare(text)
+		if err != nil {
+			return "", err
+		}
+		shares[i] = s
+	}
+	buf, err := Combine(shares)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,130 @@
+package shamir_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keng42/go/cnigma/aes"
+	"github.com/keng42/go/cnigma/shamir"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("hello world @ 2020")
+
+	shares, err := shamir.Split(secret, 5, 3)
+	require.Nil(t, err)
+	require.Len(t, shares, 5)
+
+	// every combination of 3 shares recovers the secret
+	for i := 0; i < 5; i++ {
+		for j := i + 1; j < 5; j++ {
+			for k := j + 1; k < 5; k++ {
+				got, err := shamir.Combine([]shamir.Share{shares[i], shares[j], shares[k]})
+				require.Nil(t, err)
+				require.Equal(t, secret, got)
+			}
+		}
+	}
+
+	// extra shares are fine
+	got, err := shamir.Combine(shares)
+	require.Nil(t, err)
+	require.Equal(t, secret, got)
+
+	_, err = shamir.Combine(shares[:2])
+	require.NotNil(t, err)
+
+	_, err = shamir.Combine([]shamir.Share{shares[0], shares[0], shares[1]})
+	require.NotNil(t, err)
+
+	others, err := shamir.Split(secret, 5, 4)
+	require.Nil(t, err)
+	_, err = shamir.Combine([]shamir.Share{shares[0], shares[1], others[2]})
+	require.NotNil(t, err)
+
+	// shares of another secret with the same length and threshold
+	others, err = shamir.Split([]byte("hello world @ 2021"), 5, 3)
+	require.Nil(t, err)
+	_, err = shamir.Combine([]shamir.Share{shares[0], shares[1], others[2]})
+	require.NotNil(t, err)
+
+	// the same secret split twice gives two sets that don't mix either
+	others, err = shamir.Split(secret, 5, 3)
+	require.Nil(t, err)
+	_, err = shamir.Combine([]shamir.Share{shares[0], shares[1], others[2]})
+	require.NotNil(t, err)
+
+	// a corrupted extra share is detected instead of ignored
+	corrupted := shares[4]
+	corrupted.Data = append([]byte{}, corrupted.Data...)
+	corrupted.Data[0] ^= 1
+	_, err = shamir.Combine([]shamir.Share{shares[0], shares[1], shares[2], corrupted})
+	require.NotNil(t, err)
+
+	// and so is a corrupted share among exactly threshold shares
+	_, err = shamir.Combine([]shamir.Share{shares[0], shares[1], corrupted})
+	require.NotNil(t, err)
+
+	_, err = shamir.Split(secret, 3, 1)
+	require.NotNil(t, err)
+	_, err = shamir.Split(secret, 3, 4)
+	require.NotNil(t, err)
+	_, err = shamir.Split(secret, 256, 3)
+	require.NotNil(t, err)
+	_, err = shamir.Split(nil, 3, 2)
+	require.NotNil(t, err)
+}
+
+func TestShareText(t *testing.T) {
+	shares, err := shamir.Split([]byte{0xfb, 0xff, 0xbe, 0x00, 0x01}, 3, 2)
+	require.Nil(t, err)
+
+	for _, s := range shares {
+		text := s.String()
+		require.True(t, strings.HasPrefix(text, "cnigma-share-2-"))
+
+		parsed, err := shamir.ParseShare(text + "\n")
+		require.Nil(t, err)
+		require.Equal(t, s, parsed)
+
+		// a typo breaks the checksum
+		i := len(shamir.SharePrefix) + 5
+		typo := text[:i] + string(text[i]^1) + text[i+1:]
+		_, err = shamir.ParseShare(typo)
+		require.NotNil(t, err)
+	}
+
+	_, err = shamir.ParseShare("cnigma-share-2-1-AAAA")
+	require.NotNil(t, err)
+	_, err = shamir.ParseShare("hello")
+	require.NotNil(t, err)
+}
+
+func TestSplitKey(t *testing.T) {
+	key, err := aes.NewKey(256)
+	require.Nil(t, err)
+
+	texts, err := shamir.SplitKey(key, 5, 3)
+	require.Nil(t, err)
+	require.Len(t, texts, 5)
+
+	got, err := shamir.CombineKey([]string{texts[4], texts[0], texts[2]})
+	require.Nil(t, err)
+	require.Equal(t, key, got)
+
+	// every share is checked, and shares of another key are rejected
+	got, err = shamir.CombineKey(texts)
+	require.Nil(t, err)
+	require.Equal(t, key, got)
+
+	other, err := shamir.SplitKey(key, 5, 3)
+	require.Nil(t, err)
+	_, err = shamir.CombineKey([]string{texts[0], texts[1], other[2]})
+	require.NotNil(t, err)
+	_, err = shamir.CombineKey([]string{texts[0], texts[1], texts[2], other[3]})
+	require.NotNil(t, err)
+
+	_, err = shamir.SplitKey("invalid key", 5, 3)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,58 @@
+package sqlcrypt
+
+import (
+	"errors"
+	"strings"
+)
+
+// Keyring is a Cipher that encrypts with its primary key and decrypts with
+// whichever key encrypted the value, so keys can be rotated without rewriting all rows at once.
+// Ciphertexts are prefixed with the id of the key followed by a colon,
+// values without a known prefix are decrypted with the primary key.
+type Keyring struct {
+	Primary string
+	Keys    map[string]Cipher
+}
+
+// NewKeyring returns a Keyring after checking that primary is one of keys
+func NewKeyring(primary string, keys map[string]Cipher) (*Keyring, error) {
+	for id := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, errors.New("sqlcrypt: key id must be non-empty and must not contain a colon")
+		}
+	}
+	if _, ok := keys[primary]; !ok {
+		return nil, errors.New("sqlcrypt: primary key not found")
+	}
+	return &Keyring{Primary: primary, Keys: keys}, nil
+}
+
+// EncryptText encrypts plaintext with the primary key
+func (k *Keyring) EncryptText(plaintext string, password string) (string, error) {
+	c, ok := k.Keys[k.Primary]
+	if !ok {
+		return "", errors.New("sqlcrypt: primary key not found")
+	}
+	ciphertext, err := c.EncryptText(plaintext, password)
+	if err != nil {
+		return "", err
+	}
+	return k.Primary + ":" + ciphertext, nil
+}
+
+// DecryptText decrypts ciphertext with the key named by its prefix.
+// Some encodings use colons as well, so a prefix that isn't a known key id
+// is treated as part of a value written before the keyring was introduced.
+func (k *Keyring) DecryptText(ciphertext string, password string) (string, error) {
+	if id, rest, found := strings.Cut(ciphertext, ":"); found {
+		if c, ok := k.Keys[id]; ok {
+			return c.DecryptText(rest, password)
+		}
+	}
+
+	c, ok := k.Keys[k.Primary]
+	if !ok {
+		return "", errors.New("sqlcrypt: primary key not found")
+	}
+	return c.DecryptText(ciphertext, password)
+}
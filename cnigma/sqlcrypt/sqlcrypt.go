@@ -0,0 +1,81 @@
+// Package sqlcrypt provides column types that are encrypted transparently
+// when written with database/sql and decrypted when scanned.
+//
+// The types implement driver.Valuer and sql.Scanner,
+// so they work with database/sql and ORMs built on top of it.
+// Register a cipher once during startup:
+//
+//	a, _ := aes.NewAES(types.ModeGCM, key, password, types.Base64)
+//	sqlcrypt.Register(a)
+//
+//	db.Exec("INSERT INTO users (email) VALUES (?)", sqlcrypt.EncryptedString("alice@example.com"))
+package sqlcrypt
+
+import (
+	"errors"
+	"sync"
+)
+
+// Cipher encrypts and decrypts column values.
+// It's implemented by types.AES and Keyring.
+type Cipher interface {
+	EncryptText(plain string, password string) (string, error)
+	DecryptText(cipher string, password string) (string, error)
+}
+
+var (
+	mu         sync.RWMutex
+	registered Cipher
+)
+
+// Register sets the cipher used by all column types
+func Register(c Cipher) {
+	mu.Lock()
+	defer mu.Unlock()
+	registered = c
+}
+
+// registeredCipher returns the registered cipher
+func registeredCipher() (Cipher, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if registered == nil {
+		return nil, errors.New("sqlcrypt: no cipher registered")
+	}
+	return registered, nil
+}
+
+// encrypt encrypts plaintext with the registered cipher
+func encrypt(plaintext string) (string, error) {
+	c, err := registeredCipher()
+	if err != nil {
+		return "", err
+	}
+	return c.EncryptText(plaintext, "")
+}
+
+// decrypt decrypts a scanned column value with the registered cipher.
+// ok is false if the column is NULL.
+func decrypt(src interface{}) (plaintext string, ok bool, err error) {
+	var ciphertext string
+	switch v := src.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		ciphertext = v
+	case []byte:
+		ciphertext = string(v)
+	default:
+		return "", false, errors.New("sqlcrypt: unsupported column type")
+	}
+
+	c, err := registeredCipher()
+	if err != nil {
+		return "", false, err
+	}
+	plaintext, err = c.DecryptText(ciphertext, "")
+	if err != nil {
+		return "", false, err
+	}
+	return plaintext, true, nil
+}
@@ -0,0 +1,176 @@
+package sqlcrypt_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/keng42/go/cnigma/aes"
+	"github.com/keng42/go/cnigma/aes/types"
+	"github.com/keng42/go/cnigma/sqlcrypt"
+	"github.com/stretchr/testify/require"
+)
+
+// stubDriver is a database/sql driver that appends the arguments of every Exec
+// to a single table and returns all rows on Query
+type stubDriver struct {
+	mu   sync.Mutex
+	rows [][]driver.Value
+}
+
+func (d *stubDriver) Open(string) (driver.Conn, error) { return &stubConn{d}, nil }
+
+type stubConn struct{ d *stubDriver }
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) { return &stubStmt{c.d, query}, nil }
+func (c *stubConn) Close() error                              { return nil }
+func (c *stubConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type stubStmt struct {
+	d     *stubDriver
+	query string
+}
+
+func (s *stubStmt) Close() error  { return nil }
+func (s *stubStmt) NumInput() int { return -1 }
+
+func (s *stubStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.rows = append(s.d.rows, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stubStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	return &stubRows{rows: append([][]driver.Value{}, s.d.rows...)}, nil
+}
+
+type stubRows struct{ rows [][]driver.Value }
+
+func (r *stubRows) Columns() []string { return []string{"a", "b", "c"} }
+func (r *stubRows) Close() error      { return nil }
+
+func (r *stubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var stub = &stubDriver{}
+
+func init() {
+	sql.Register("sqlcrypt-stub", stub)
+}
+
+type Card struct {
+	Number string `json:"number"`
+	CVC    int    `json:"cvc"`
+}
+
+func TestColumns(t *testing.T) {
+	a, err := aes.NewAES(types.ModeGCM, "", "my-password", types.Base64)
+	require.Nil(t, err)
+	sqlcrypt.Register(a)
+
+	db, err := sql.Open("sqlcrypt-stub", "")
+	require.Nil(t, err)
+	defer db.Close()
+
+	stub.rows = nil
+	card := sqlcrypt.EncryptedJSON[Card]{Data: Card{Number: "4111111111111111", CVC: 123}}
+	_, err = db.Exec("INSERT INTO t VALUES (?, ?, ?)",
+		sqlcrypt.EncryptedString("alice@example.com"),
+		sqlcrypt.EncryptedBytes{0x00, 0xff},
+		card,
+	)
+	require.Nil(t, err)
+	_, err = db.Exec("INSERT INTO t VALUES (?, ?, ?)", nil, sqlcrypt.EncryptedBytes(nil), nil)
+	require.Nil(t, err)
+
+	// values reach the driver encrypted
+	stored := stub.rows[0]
+	require.IsType(t, "", stored[0])
+	require.NotContains(t, stored[0], "alice")
+	require.NotContains(t, stored[2], "4111")
+
+	rows, err := db.Query("SELECT a, b, c FROM t")
+	require.Nil(t, err)
+	defer rows.Close()
+
+	var email sqlcrypt.EncryptedString
+	var data sqlcrypt.EncryptedBytes
+	var decoded sqlcrypt.EncryptedJSON[Card]
+
+	require.True(t, rows.Next())
+	require.Nil(t, rows.Scan(&email, &data, &decoded))
+	require.Equal(t, sqlcrypt.EncryptedString("alice@example.com"), email)
+	require.Equal(t, sqlcrypt.EncryptedBytes{0x00, 0xff}, data)
+	require.Equal(t, card, decoded)
+
+	require.True(t, rows.Next())
+	require.Nil(t, rows.Scan(&email, &data, &decoded))
+	require.Equal(t, sqlcrypt.EncryptedString(""), email)
+	require.Nil(t, data)
+	require.Equal(t, Card{}, decoded.Data)
+
+	require.False(t, rows.Next())
+}
+
+func TestKeyring(t *testing.T) {
+	oldKey, err := aes.NewAES(types.ModeGCM, "", "my-password", types.Base64)
+	require.Nil(t, err)
+	key, err := aes.NewKey(256)
+	require.Nil(t, err)
+	newKey, err := aes.NewAES(types.ModeGCM, key, "my-password", types.Base64)
+	require.Nil(t, err)
+
+	legacy, err := oldKey.EncryptText("alice@example.com", "")
+	require.Nil(t, err)
+
+	ring, err := sqlcrypt.NewKeyring("v1", map[string]sqlcrypt.Cipher{"v1": oldKey})
+	require.Nil(t, err)
+	v1, err := ring.EncryptText("alice@example.com", "")
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(v1, "v1:"))
+
+	// rotate to a new primary key, old values still decrypt
+	ring, err = sqlcrypt.NewKeyring("v2", map[string]sqlcrypt.Cipher{"v1": oldKey, "v2": newKey})
+	require.Nil(t, err)
+	v2, err := ring.EncryptText("alice@example.com", "")
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(v2, "v2:"))
+
+	for _, ciphertext := range []string{v1, v2} {
+		plaintext, err := ring.DecryptText(ciphertext, "")
+		require.Nil(t, err)
+		require.Equal(t, "alice@example.com", plaintext)
+	}
+
+	// values without a prefix use the primary key
+	_, err = ring.DecryptText(legacy, "")
+	require.NotNil(t, err)
+	ring.Primary = "v1"
+	plaintext, err := ring.DecryptText(legacy, "")
+	require.Nil(t, err)
+	require.Equal(t, "alice@example.com", plaintext)
+
+	_, err = sqlcrypt.NewKeyring("v3", map[string]sqlcrypt.Cipher{"v1": oldKey})
+	require.NotNil(t, err)
+	_, err = sqlcrypt.NewKeyring("a:b", map[string]sqlcrypt.Cipher{"a:b": oldKey})
+	require.NotNil(t, err)
+
+	// a keyring can be registered like any cipher
+	sqlcrypt.Register(ring)
+	var email sqlcrypt.EncryptedString
+	require.Nil(t, email.Scan(v2))
+	require.Equal(t, sqlcrypt.EncryptedString("alice@example.com"), email)
+}
@@ -0,0 +1,82 @@
+package sqlcrypt
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// EncryptedString is a string column that is stored encrypted.
+// NULL is scanned as an empty string.
+type EncryptedString string
+
+// Value implements driver.Valuer
+func (s EncryptedString) Value() (driver.Value, error) {
+	return encrypt(string(s))
+}
+
+// Scan implements sql.Scanner
+func (s *EncryptedString) Scan(src interface{}) error {
+	plaintext, _, err := decrypt(src)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// EncryptedBytes is a binary column that is stored encrypted.
+// A nil value is stored as NULL and NULL is scanned as nil.
+type EncryptedBytes []byte
+
+// Value implements driver.Valuer
+func (b EncryptedBytes) Value() (driver.Value, error) {
+	if b == nil {
+		return nil, nil
+	}
+	return encrypt(string(b))
+}
+
+// Scan implements sql.Scanner
+func (b *EncryptedBytes) Scan(src interface{}) error {
+	plaintext, ok, err := decrypt(src)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		*b = nil
+		return nil
+	}
+	*b = EncryptedBytes(plaintext)
+	return nil
+}
+
+// EncryptedJSON is a column holding the json form of Data, stored encrypted.
+// NULL is scanned as the zero value.
+type EncryptedJSON[T any] struct {
+	Data T
+}
+
+// Value implements driver.Valuer
+func (j EncryptedJSON[T]) Value() (driver.Value, error) {
+	buf, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, err
+	}
+	return encrypt(string(buf))
+}
+
+// Scan implements sql.Scanner
+func (j *EncryptedJSON[T]) Scan(src interface{}) error {
+	plaintext, ok, err := decrypt(src)
+	if err != nil {
+		return err
+	}
+	var v T
+	if ok {
+		if err := json.Unmarshal([]byte(plaintext), &v); err != nil {
+			return err
+		}
+	}
+	j.Data = v
+	return nil
+}
@@ -0,0 +1,21 @@
+package op
+
+// Coalesce returns the first non-zero value, or the zero value if all are zero
+func Coalesce[T comparable](values ...T) T {
+	var zero T
+	for _, v := range values {
+		if v != zero {
+			return v
+		}
+	}
+	return zero
+}
+
+// Default returns value, or def if value is the zero value
+func Default[T comparable](value, def T) T {
+	var zero T
+	if value == zero {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,26 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/keng42/go/op"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalesce(t *testing.T) {
+	require.Equal(t, "b", op.Coalesce("", "b", "c"))
+	require.Equal(t, 3, op.Coalesce(0, 0, 3))
+	require.Equal(t, "", op.Coalesce[string]())
+	require.Equal(t, 0, op.Coalesce(0, 0))
+
+	a := 1
+	var p *int
+	require.Equal(t, &a, op.Coalesce(p, &a))
+}
+
+func TestDefault(t *testing.T) {
+	require.Equal(t, "localhost", op.Default("", "localhost"))
+	require.Equal(t, "example.com", op.Default("example.com", "localhost"))
+	require.Equal(t, 8080, op.Default(0, 8080))
+	require.Equal(t, 443, op.Default(443, 8080))
+}
@@ -8,3 +8,11 @@ func If[T any](con bool, trueVal, falseVal T) T {
 	}
 	return falseVal
 }
+
+// IfFunc is the lazy version of If, only the chosen branch is evaluated
+func IfFunc[T any](con bool, trueFn, falseFn func() T) T {
+	if con {
+		return trueFn()
+	}
+	return falseFn()
+}
@@ -11,3 +11,23 @@ func TestIf(t *testing.T) {
 	require.Equal(t, 1, op.If(true, 1, 0))
 	require.Equal(t, "not ok", op.If(false, "ok", "not ok"))
 }
+
+func TestIfFunc(t *testing.T) {
+	type user struct{ Name string }
+	var p *user
+
+	// the nil pointer is only dereferenced in the branch that isn't taken
+	name := op.IfFunc(p != nil, func() string { return p.Name }, func() string { return "" })
+	require.Equal(t, "", name)
+
+	p = &user{Name: "alice"}
+	name = op.IfFunc(p != nil, func() string { return p.Name }, func() string { return "" })
+	require.Equal(t, "alice", name)
+
+	calls := 0
+	expensive := func() int { calls++; return 42 }
+	require.Equal(t, 0, op.IfFunc(false, expensive, func() int { return 0 }))
+	require.Equal(t, 0, calls)
+	require.Equal(t, 42, op.IfFunc(true, expensive, func() int { return 0 }))
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,47 @@
+package op
+
+// SwitchExpr is a switch expression built by Switch, the first matching case wins
+type SwitchExpr[T comparable, R any] struct {
+	value   T
+	matched bool
+	result  R
+}
+
+// Switch starts a switch expression on value, e.g.
+//
+//	op.Switch[string, int](s).Case("one", 1).Case("two", 2).Default(0)
+func Switch[T comparable, R any](value T) SwitchExpr[T, R] {
+	return SwitchExpr[T, R]{value: value}
+}
+
+// Case returns result if value equals match and no earlier case matched
+func (s SwitchExpr[T, R]) Case(match T, result R) SwitchExpr[T, R] {
+	if !s.matched && s.value == match {
+		s.matched, s.result = true, result
+	}
+	return s
+}
+
+// CaseFunc is the lazy version of Case, fn is only called if the case is chosen
+func (s SwitchExpr[T, R]) CaseFunc(match T, fn func() R) SwitchExpr[T, R] {
+	if !s.matched && s.value == match {
+		s.matched, s.result = true, fn()
+	}
+	return s
+}
+
+// Default ends the expression and returns result if no case matched
+func (s SwitchExpr[T, R]) Default(result R) R {
+	if s.matched {
+		return s.result
+	}
+	return result
+}
+
+// DefaultFunc is the lazy version of Default, fn is only called if no case matched
+func (s SwitchExpr[T, R]) DefaultFunc(fn func() R) R {
+	if s.matched {
+		return s.result
+	}
+	return fn()
+}
@@ -0,0 +1,35 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/keng42/go/op"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwitch(t *testing.T) {
+	number := func(s string) int {
+		return op.Switch[string, int](s).Case("one", 1).Case("two", 2).Default(0)
+	}
+	require.Equal(t, 1, number("one"))
+	require.Equal(t, 2, number("two"))
+	require.Equal(t, 0, number("three"))
+
+	// the first matching case wins
+	require.Equal(t, "first", op.Switch[int, string](1).Case(1, "first").Case(1, "second").Default("none"))
+
+	// conditions instead of values
+	x := 15
+	size := op.Switch[bool, string](true).Case(x < 10, "small").Case(x < 100, "medium").Default("large")
+	require.Equal(t, "medium", size)
+
+	// lazy cases only run when chosen
+	calls := 0
+	expensive := func() int { calls++; return 42 }
+	require.Equal(t, 1, op.Switch[string, int]("a").Case("a", 1).CaseFunc("a", expensive).DefaultFunc(expensive))
+	require.Equal(t, 0, calls)
+	require.Equal(t, 42, op.Switch[string, int]("b").Case("a", 1).CaseFunc("b", expensive).DefaultFunc(expensive))
+	require.Equal(t, 1, calls)
+	require.Equal(t, 42, op.Switch[string, int]("c").Case("a", 1).DefaultFunc(expensive))
+	require.Equal(t, 2, calls)
+}
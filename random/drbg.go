@@ -0,0 +1,206 @@
+package random
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+)
+
+// DRBG constants of CTR_DRBG with AES-256 and no derivation function
+const (
+	DRBGSeedSize       = 48      // length of entropy input, personalization and additional input
+	drbgKeySize        = 32      // aes-256
+	drbgMaxRequest     = 1 << 16 // 2^19 bits per generate request
+	drbgReseedInterval = 1 << 48 // generate requests between reseeds
+)
+
+// DRBG is a NIST SP 800-90A CTR_DRBG using AES-256 without a derivation function.
+// It implements io.Reader and is safe for concurrent use, so it can drive a Generator.
+type DRBG struct {
+	mu            sync.Mutex
+	block         cipher.Block
+	v             [aes.BlockSize]byte
+	reseedCounter uint64
+	entropy       io.Reader // nil for seeded DRBGs
+
+	// PredictionResistance reseeds from the entropy source before every request
+	PredictionResistance bool
+}
+
+// NewDRBG returns a DRBG instantiated with entropy from the source,
+// crypto/rand is used if entropy is nil. The personalization string is optional.
+func NewDRBG(entropy io.Reader, personalization []byte) (*DRBG, error) {
+	if entropy == nil {
+		entropy = rand.Reader
+	}
+	seed := make([]byte, DRBGSeedSize)
+	if _, err := io.ReadFull(entropy, seed); err != nil {
+		return nil, err
+	}
+	d, err := NewSeededDRBG(seed, personalization)
+	if err != nil {
+		return nil, err
+	}
+	d.entropy = entropy
+	return d, nil
+}
+
+// NewSeededDRBG returns a DRBG instantiated with a 48 bytes seed as the entropy input.
+// The same seed and personalization string always produce the same output,
+// which is useful for reproducible fixtures and simulations but not for secrets.
+// Seeded DRBGs have no entropy source, so they only reseed with explicit entropy.
+func NewSeededDRBG(seed, personalization []byte) (*DRBG, error) {
+	if len(seed) != DRBGSeedSize {
+		return nil, errors.New("drbg seed requires 48 bytes")
+	}
+	if len(personalization) > DRBGSeedSize {
+		return nil, errors.New("drbg personalization string is longer than 48 bytes")
+	}
+
+	d := &DRBG{}
+	block, err := aes.NewCipher(make([]byte, drbgKeySize))
+	if err != nil {
+		return nil, err
+	}
+	d.block = block
+	if err := d.update(xorPadded(seed, personalization)); err != nil {
+		return nil, err
+	}
+	d.reseedCounter = 1
+	return d, nil
+}
+
+// Reseed mixes new entropy and optional additional input into the state.
+// The entropy is read from the source if nil, and must be 48 bytes otherwise.
+func (d *DRBG) Reseed(entropy, additional []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.reseed(entropy, additional)
+}
+
+func (d *DRBG) reseed(entropy, additional []byte) error {
+	if entropy == nil {
+		if d.entropy == nil {
+			return errors.New("drbg has no entropy source")
+		}
+		entropy = make([]byte, DRBGSeedSize)
+		if _, err := io.ReadFull(d.entropy, entropy); err != nil {
+			return err
+		}
+	}
+	if len(entropy) != DRBGSeedSize {
+		return errors.New("drbg entropy requires 48 bytes")
+	}
+	if len(additional) > DRBGSeedSize {
+		return errors.New("drbg additional input is longer than 48 bytes")
+	}
+	if err := d.update(xorPadded(entropy, additional)); err != nil {
+		return err
+	}
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate fills p with pseudorandom bytes, the additional input is optional.
+// Requests longer than 64 KiB are split and the additional input only applies to the first one.
+func (d *DRBG) Generate(p, additional []byte) error {
+	if len(additional) > DRBGSeedSize {
+		return errors.New("drbg additional input is longer than 48 bytes")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for len(p) > 0 {
+		n := len(p)
+		if n > drbgMaxRequest {
+			n = drbgMaxRequest
+		}
+		if err := d.generate(p[:n], additional); err != nil {
+			return err
+		}
+		p = p[n:]
+		additional = nil
+	}
+	return nil
+}
+
+// Read implements io.Reader, it always fills p unless an error occurs
+func (d *DRBG) Read(p []byte) (int, error) {
+	if err := d.Generate(p, nil); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (d *DRBG) generate(p, additional []byte) error {
+	if d.PredictionResistance || d.reseedCounter > drbgReseedInterval {
+		if err := d.reseed(nil, additional); err != nil {
+			return err
+		}
+		additional = nil
+	}
+
+	padded := make([]byte, DRBGSeedSize)
+	if len(additional) > 0 {
+		copy(padded, additional)
+		if err := d.update(padded); err != nil {
+			return err
+		}
+	}
+
+	var block [aes.BlockSize]byte
+	for len(p) > 0 {
+		increment(&d.v)
+		d.block.Encrypt(block[:], d.v[:])
+		p = p[copy(p, block[:]):]
+	}
+
+	if err := d.update(padded); err != nil {
+		return err
+	}
+	d.reseedCounter++
+	return nil
+}
+
+// update is the CTR_DRBG_Update function, data must be 48 bytes
+func (d *DRBG) update(data []byte) error {
+	temp := make([]byte, DRBGSeedSize)
+	for i := 0; i < DRBGSeedSize; i += aes.BlockSize {
+		increment(&d.v)
+		d.block.Encrypt(temp[i:], d.v[:])
+	}
+	for i := range temp {
+		temp[i] ^= data[i]
+	}
+
+	block, err := aes.NewCipher(temp[:drbgKeySize])
+	if err != nil {
+		return err
+	}
+	d.block = block
+	copy(d.v[:], temp[drbgKeySize:])
+	return nil
+}
+
+// increment adds one to v as a big-endian 128-bit counter
+func increment(v *[aes.BlockSize]byte) {
+	for i := len(v) - 1; i >= 0; i-- {
+		v[i]++
+		if v[i] != 0 {
+			return
+		}
+	}
+}
+
+// xorPadded returns a xor b, b is padded with zeros to the length of a
+func xorPadded(a, b []byte) []byte {
+	out := append([]byte{}, a...)
+	for i := range b {
+		out[i] ^= b[i]
+	}
+	return out
+}
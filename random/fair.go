@@ -0,0 +1,269 @@
+package random
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// DrawScheme identifies how outcomes are derived from the seeds and the entrants
+const DrawScheme = "cnigma-draw-v2"
+
+// Limits of a draw, transcripts beyond them are rejected before any work is done
+const (
+	MaxDrawEntrants = 1<<31 - 1
+	MaxDrawWinners  = 1 << 16
+)
+
+// Signer signs a transcript, *rsa.RSA implements it
+type Signer interface {
+	Sign(msg string) (string, error)
+}
+
+// SignatureVerifier verifies the signature of a transcript, *rsa.RSA implements it
+type SignatureVerifier interface {
+	Verify(msg, sig string) (bool, error)
+}
+
+// Draw is a commit-reveal random draw.
+// The commitment is published before the draw, the server seed is revealed in the transcript after it,
+// so anyone can check that the outcome follows from seeds the server couldn't choose alone.
+// The commitment covers the list of entrants too, so it can't be reordered once the seeds are known.
+type Draw struct {
+	mu           sync.Mutex
+	seed         []byte
+	entrantsHash []byte
+	entrants     int
+	drawn        bool
+
+	Commitment string // hex sha256 of the server seed followed by hex sha256 of the entrants
+}
+
+// Transcript records a draw so that it can be verified and signed
+type Transcript struct {
+	Scheme       string   `json:"scheme"`
+	Commitment   string   `json:"commitment"`
+	ServerSeed   string   `json:"serverSeed"` // hex, revealed after the draw
+	ClientSeeds  []string `json:"clientSeeds"`
+	Entrants     int      `json:"entrants"`
+	EntrantsHash string   `json:"entrantsHash"` // hex sha256 of the entrants
+	Winners      []int    `json:"winners"`      // indexes of the entrants in winning order
+	Signature    string   `json:"signature,omitempty"`
+}
+
+// NewDraw returns a draw of the entrants with a random 256-bit server seed.
+// The entrants are published along with the commitment, in this order.
+func NewDraw(entrants []string) (*Draw, error) {
+	if len(entrants) < 1 || len(entrants) > MaxDrawEntrants {
+		return nil, errors.New("entrants must be between 1 and MaxDrawEntrants")
+	}
+	seed, err := Bytes(32)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(seed)
+	entrantsHash := hashEntrants(entrants)
+	return &Draw{
+		seed:         seed,
+		entrantsHash: entrantsHash,
+		entrants:     len(entrants),
+		Commitment:   hex.EncodeToString(sum[:]) + hex.EncodeToString(entrantsHash),
+	}, nil
+}
+
+// Winners draws k distinct winners out of the entrants, combining the server seed with the client
+// or public seeds such as a future block hash. A draw can only be run once.
+func (d *Draw) Winners(k int, clientSeeds ...string) (Transcript, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.drawn {
+		return Transcript{}, errors.New("the draw has already been run")
+	}
+
+	winners, err := drawWinners(d.seed, d.entrantsHash, clientSeeds, d.entrants, k)
+	if err != nil {
+		return Transcript{}, err
+	}
+	d.drawn = true
+
+	return Transcript{
+		Scheme:       DrawScheme,
+		Commitment:   d.Commitment,
+		ServerSeed:   hex.EncodeToString(d.seed),
+		ClientSeeds:  append([]string{}, clientSeeds...),
+		Entrants:     d.entrants,
+		EntrantsHash: hex.EncodeToString(d.entrantsHash),
+		Winners:      winners,
+	}, nil
+}
+
+// Message returns the canonical text of the transcript that is signed
+func (t Transcript) Message() string {
+	t.Signature = ""
+	buf, _ := json.Marshal(t)
+	return string(buf)
+}
+
+// Sign signs the transcript message, e.g. with rsa.RSA.Sign
+func (t *Transcript) Sign(s Signer) error {
+	sig, err := s.Sign(t.Message())
+	if err != nil {
+		return err
+	}
+	t.Signature = sig
+	return nil
+}
+
+// VerifyTranscript checks the commitment against the published entrants, recomputes the winners,
+// and verifies the signature if v is not nil.
+func VerifyTranscript(t Transcript, entrants []string, v SignatureVerifier) error {
+	if t.Scheme != DrawScheme {
+		return errors.New("unsupported draw scheme")
+	}
+	// the transcript may come from anyone, so its sizes are checked before drawing
+	if t.Entrants != len(entrants) || t.Entrants < 1 || t.Entrants > MaxDrawEntrants || len(t.Winners) > MaxDrawWinners {
+		return errors.New("transcript sizes are out of range or don't match the entrants")
+	}
+	entrantsHash := hashEntrants(entrants)
+	if t.EntrantsHash != hex.EncodeToString(entrantsHash) {
+		return errors.New("entrants don't match the transcript")
+	}
+
+	seed, err := hex.DecodeString(t.ServerSeed)
+	if err != nil {
+		return errors.New("invalid server seed")
+	}
+	sum := sha256.Sum256(seed)
+	commitment, err := hex.DecodeString(t.Commitment)
+	if err != nil || subtle.ConstantTimeCompare(append(sum[:], entrantsHash...), commitment) != 1 {
+		return errors.New("server seed or entrants don't match the commitment")
+	}
+
+	winners, err := drawWinners(seed, entrantsHash, t.ClientSeeds, t.Entrants, len(t.Winners))
+	if err != nil {
+		return err
+	}
+	for i := range winners {
+		if winners[i] != t.Winners[i] {
+			return errors.New("winners don't follow from the seeds")
+		}
+	}
+
+	if v != nil {
+		if t.Signature == "" {
+			return errors.New("transcript is not signed")
+		}
+		ok, err := v.Verify(t.Message(), t.Signature)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("invalid transcript signature")
+		}
+	}
+	return nil
+}
+
+// drawWinners derives the winners of cnigma-draw-v2 on its own,
+// so that changes to the other helpers of the package can't break published transcripts.
+//
+// Winner i is position i of a Fisher-Yates shuffle of [0, entrants): with m = entrants - i,
+// the next 8 bytes of the stream are read as a big-endian x, x is redrawn while
+// x >= 2^64 - (2^64 mod m), then position i is swapped with position i + x mod m.
+// Only the swapped positions are stored, so memory is O(k) whatever the number of entrants.
+func drawWinners(seed, entrantsHash []byte, clientSeeds []string, entrants, k int) ([]int, error) {
+	if len(clientSeeds) == 0 {
+		return nil, errors.New("at least one client seed is required")
+	}
+	if entrants < 1 || entrants > MaxDrawEntrants {
+		return nil, errors.New("entrants must be between 1 and MaxDrawEntrants")
+	}
+	if k < 0 || k > entrants || k > MaxDrawWinners {
+		return nil, errors.New("winners must be between 0 and the number of entrants")
+	}
+
+	stream := newDrawStream(seed, entrantsHash, clientSeeds)
+	moved := map[int]int{}
+	at := func(i int) int {
+		if v, ok := moved[i]; ok {
+			return v
+		}
+		return i
+	}
+
+	winners := make([]int, k)
+	var buf [8]byte
+	for i := range winners {
+		m := uint64(entrants - i)
+		limit := -(-m % m) // 2^64 - (2^64 mod m), 0 means 2^64
+		var x uint64
+		for {
+			stream.Read(buf[:])
+			x = binary.BigEndian.Uint64(buf[:])
+			if limit == 0 || x < limit {
+				break
+			}
+		}
+		j := i + int(x%m)
+		winners[i] = at(j)
+		moved[j] = at(i)
+	}
+	return winners, nil
+}
+
+// hashEntrants returns sha256 of the count of entrants followed by every length-prefixed entrant
+func hashEntrants(entrants []string) []byte {
+	h := sha256.New()
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(entrants)))
+	h.Write(size[:])
+	for _, e := range entrants {
+		binary.BigEndian.PutUint32(size[:], uint32(len(e)))
+		h.Write(size[:])
+		h.Write([]byte(e))
+	}
+	return h.Sum(nil)
+}
+
+// drawStream is the keystream HMAC-SHA256(server seed, scheme || entrants hash || seeds || counter)
+type drawStream struct {
+	prefix  []byte // scheme, entrants hash and length-prefixed client seeds
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func newDrawStream(seed, entrantsHash []byte, clientSeeds []string) *drawStream {
+	msg := append([]byte(DrawScheme), entrantsHash...)
+	var size [4]byte
+	for _, s := range clientSeeds {
+		binary.BigEndian.PutUint32(size[:], uint32(len(s)))
+		msg = append(msg, size[:]...)
+		msg = append(msg, s...)
+	}
+	return &drawStream{prefix: msg, seed: seed}
+}
+
+func (s *drawStream) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(s.buf) == 0 {
+			h := hmac.New(sha256.New, s.seed)
+			h.Write(s.prefix)
+			var counter [8]byte
+			binary.BigEndian.PutUint64(counter[:], s.counter)
+			h.Write(counter[:])
+			s.buf = h.Sum(nil)
+			s.counter++
+		}
+		c := copy(p[n:], s.buf)
+		s.buf = s.buf[c:]
+		n += c
+	}
+	return n, nil
+}
@@ -0,0 +1,98 @@
+package random
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+	"sync"
+)
+
+// Generator draws all random values from one source,
+// the package-level functions use a generator reading from crypto/rand.
+// Use a DRBG as the source for reproducible values.
+type Generator struct {
+	reader io.Reader
+
+	mu       sync.Mutex
+	lastUUID uint64 // unix milliseconds << 12 | sub-millisecond fraction of the last v7 uuid
+	lastULID ULID
+}
+
+// NewGenerator returns a Generator reading from r, which must be safe for concurrent use
+// if the generator is shared between goroutines.
+func NewGenerator(r io.Reader) *Generator {
+	return &Generator{reader: r}
+}
+
+var defaultGenerator = NewGenerator(rand.Reader)
+
+// Bytes returns n random bytes
+func Bytes(n int) ([]byte, error) {
+	return defaultGenerator.Bytes(n)
+}
+
+// Int returns a uniform random value in [0, max), it returns an error if max <= 0
+func Int(max *big.Int) (*big.Int, error) {
+	return defaultGenerator.Int(max)
+}
+
+// Intn returns a uniform random int in [0, n) using rejection sampling, it returns an error if n <= 0
+func Intn(n int) (int, error) {
+	return defaultGenerator.Intn(n)
+}
+
+// String returns n characters drawn uniformly from the alphabet
+func String(alphabet string, n int) (string, error) {
+	return defaultGenerator.String(alphabet, n)
+}
+
+// Hex returns n random lowercase hex characters
+func Hex(n int) (string, error) {
+	return defaultGenerator.Hex(n)
+}
+
+// Base64URL returns n random characters of the url-safe base64 alphabet
+func Base64URL(n int) (string, error) {
+	return defaultGenerator.Base64URL(n)
+}
+
+// Ascii85 returns n random characters of the ascii85 alphabet
+func Ascii85(n int) (string, error) {
+	return defaultGenerator.Ascii85(n)
+}
+
+// Password generates a password that satisfies the policy, see Generator.Password
+func Password(policy PasswordPolicy) (Secret, error) {
+	return defaultGenerator.Password(policy)
+}
+
+// Passphrase generates a diceware-style passphrase, see Generator.Passphrase
+func Passphrase(opts PassphraseOptions) (Secret, error) {
+	return defaultGenerator.Passphrase(opts)
+}
+
+// UUIDv4 returns a random uuid
+func UUIDv4() (UUID, error) {
+	return defaultGenerator.UUIDv4()
+}
+
+// UUIDv7 returns a time-ordered uuid, see Generator.UUIDv7
+func UUIDv7() (UUID, error) {
+	return defaultGenerator.UUIDv7()
+}
+
+// NewULID returns a ulid with random bits
+func NewULID() (ULID, error) {
+	return defaultGenerator.NewULID()
+}
+
+// NewMonotonicULID returns a ulid that is greater than the previous one from this process,
+// see Generator.NewMonotonicULID
+func NewMonotonicULID() (ULID, error) {
+	return defaultGenerator.NewMonotonicULID()
+}
+
+// NanoID returns a random id of size characters from the alphabet, see Generator.NanoID
+func NanoID(alphabet string, size int) (string, error) {
+	return defaultGenerator.NanoID(alphabet, size)
+}
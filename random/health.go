@@ -0,0 +1,166 @@
+package random
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+)
+
+// ErrHealthTest is returned by a HealthReader once its entropy source failed a health test
+var ErrHealthTest = errors.New("entropy source failed a health test")
+
+// aptWindow is the adaptive proportion test window size for non-binary samples
+const aptWindow = 512
+
+// startupSamples are tested and discarded before the first output
+const startupSamples = 1024
+
+// HealthConfig configures the cutoffs of the health tests
+type HealthConfig struct {
+	MinEntropy    float64 // assessed min-entropy per byte, defaults to 8
+	FalsePositive int     // the false positive probability is 2^-FalsePositive per sample, defaults to 40
+}
+
+// HealthStats are the counters of a HealthReader for monitoring
+type HealthStats struct {
+	Samples     uint64 // bytes tested, including the startup test
+	Windows     uint64 // completed adaptive proportion test windows
+	RCTFailures uint64 // repetition count test failures
+	APTFailures uint64 // adaptive proportion test failures
+	MaxRun      int    // longest run of identical bytes seen
+	MaxCount    int    // highest count of the first byte in a window seen
+	RCTCutoff   int    // runs of this length fail the repetition count test
+	APTCutoff   int    // counts of this value fail the adaptive proportion test
+}
+
+// HealthReader wraps an entropy source and runs the NIST SP 800-90B continuous health tests,
+// the repetition count test and the adaptive proportion test, on every byte it reads.
+// Once a test fails, every read returns ErrHealthTest.
+type HealthReader struct {
+	mu     sync.Mutex
+	r      io.Reader
+	stats  HealthStats
+	failed bool
+
+	last   byte // repetition count test state
+	run    int
+	first  byte // adaptive proportion test state
+	count  int
+	window int
+}
+
+// NewHealthReader wraps r and runs the startup test on 1024 bytes, which are discarded
+func NewHealthReader(r io.Reader, config HealthConfig) (*HealthReader, error) {
+	if config.MinEntropy == 0 {
+		config.MinEntropy = 8
+	}
+	if config.FalsePositive == 0 {
+		config.FalsePositive = 40
+	}
+	if config.MinEntropy < 0 || config.MinEntropy > 8 || config.FalsePositive < 20 || config.FalsePositive > 60 {
+		return nil, errors.New("health test requires 0 < min-entropy <= 8 and 20 <= false positive <= 60")
+	}
+
+	h := &HealthReader{r: r}
+	h.stats.RCTCutoff = 1 + int(math.Ceil(float64(config.FalsePositive)/config.MinEntropy))
+	h.stats.APTCutoff = 1 + critBinom(aptWindow, math.Pow(2, -config.MinEntropy), config.FalsePositive)
+
+	buf := make([]byte, startupSamples)
+	if _, err := io.ReadFull(h, buf); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Read reads from the entropy source and tests the bytes before returning them
+func (h *HealthReader) Read(p []byte) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.failed {
+		return 0, ErrHealthTest
+	}
+	n, err := h.r.Read(p)
+	for _, b := range p[:n] {
+		if !h.test(b) {
+			h.failed = true
+			for i := range p[:n] {
+				p[i] = 0
+			}
+			return 0, ErrHealthTest
+		}
+	}
+	return n, err
+}
+
+// Stats returns a snapshot of the counters
+func (h *HealthReader) Stats() HealthStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stats
+}
+
+// test runs both tests on the next sample and reports whether they passed
+func (h *HealthReader) test(b byte) bool {
+	passed := true
+	h.stats.Samples++
+
+	// repetition count test
+	if h.stats.Samples > 1 && b == h.last {
+		h.run++
+	} else {
+		h.last, h.run = b, 1
+	}
+	if h.run > h.stats.MaxRun {
+		h.stats.MaxRun = h.run
+	}
+	if h.run >= h.stats.RCTCutoff {
+		h.stats.RCTFailures++
+		passed = false
+	}
+
+	// adaptive proportion test
+	if h.window == 0 {
+		h.first, h.count = b, 0
+	}
+	if b == h.first {
+		h.count++
+	}
+	if h.count > h.stats.MaxCount {
+		h.stats.MaxCount = h.count
+	}
+	if h.count >= h.stats.APTCutoff {
+		h.stats.APTFailures++
+		passed = false
+	}
+	h.window++
+	if h.window == aptWindow {
+		h.stats.Windows++
+		h.window = 0
+	}
+
+	return passed
+}
+
+// critBinom returns the smallest k with P(X > k) <= 2^-alpha for X ~ Binomial(n, p)
+func critBinom(n int, p float64, alpha int) int {
+	limit := math.Pow(2, -float64(alpha))
+	for k := 0; k < n; k++ {
+		tail := 0.0
+		for j := k + 1; j <= n; j++ {
+			tail += math.Exp(lchoose(n, j) + float64(j)*math.Log(p) + float64(n-j)*math.Log1p(-p))
+		}
+		if tail <= limit {
+			return k
+		}
+	}
+	return n
+}
+
+func lchoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
@@ -0,0 +1,214 @@
+package random
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// UUID is a RFC 9562 universally unique identifier
+type UUID [16]byte
+
+// UUIDv4 returns a random uuid
+func (g *Generator) UUIDv4() (UUID, error) {
+	var u UUID
+	b, err := g.Bytes(16)
+	if err != nil {
+		return u, err
+	}
+	copy(u[:], b)
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+// UUIDv7 returns a time-ordered uuid.
+// The 12 bits after the millisecond timestamp hold the sub-millisecond fraction
+// and are incremented if needed, so uuids from the same generator are strictly increasing.
+func (g *Generator) UUIDv7() (UUID, error) {
+	var u UUID
+	b, err := g.Bytes(10)
+	if err != nil {
+		return u, err
+	}
+
+	now := time.Now().UnixNano()
+	ts := uint64(now/1e6)<<12 | uint64(now%1e6)*4096/1e6
+	g.mu.Lock()
+	if ts <= g.lastUUID {
+		ts = g.lastUUID + 1
+	}
+	g.lastUUID = ts
+	g.mu.Unlock()
+
+	binary.BigEndian.PutUint64(u[:8], ts>>12<<16) // 48 bits of milliseconds
+	u[6] = 0x70 | byte(ts>>8)&0x0f
+	u[7] = byte(ts)
+	copy(u[8:], b[2:])
+	u[8] = u[8]&0x3f | 0x80
+	return u, nil
+}
+
+// ParseUUID parses the canonical form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx, optionally prefixed with urn:uuid:
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	s = strings.TrimPrefix(strings.ToLower(s), "urn:uuid:")
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, errors.New("invalid uuid format")
+	}
+	h := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(h)); err != nil {
+		return u, errors.New("invalid uuid format")
+	}
+	return u, nil
+}
+
+// String returns the canonical form of the uuid
+func (u UUID) String() string {
+	h := hex.EncodeToString(u[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// Version returns the version number of the uuid
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Time returns the timestamp of a v7 uuid with millisecond precision
+func (u UUID) Time() time.Time {
+	ms := binary.BigEndian.Uint64(u[:8]) >> 16
+	return time.UnixMilli(int64(ms))
+}
+
+// ULID is a universally unique lexicographically sortable identifier,
+// a 48-bit millisecond timestamp followed by 80 random bits.
+type ULID [16]byte
+
+// crockford is the Crockford's base32 alphabet used by ulids
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ulid with random bits
+func (g *Generator) NewULID() (ULID, error) {
+	var u ULID
+	b, err := g.Bytes(10)
+	if err != nil {
+		return u, err
+	}
+	putULIDTime(&u, time.Now())
+	copy(u[6:], b)
+	return u, nil
+}
+
+// NewMonotonicULID returns a ulid that is greater than the previous one from the same generator.
+// Within the same millisecond the random bits of the previous ulid are incremented by one.
+func (g *Generator) NewMonotonicULID() (ULID, error) {
+	u, err := g.NewULID()
+	if err != nil {
+		return u, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	last := g.lastULID
+	if string(u[:6]) <= string(last[:6]) {
+		u = last
+		i := 15
+		for ; i >= 6; i-- {
+			u[i]++
+			if u[i] != 0 {
+				break
+			}
+		}
+		if i < 6 {
+			return ULID{}, errors.New("ulid random bits overflow within the same millisecond")
+		}
+	}
+	g.lastULID = u
+	return u, nil
+}
+
+// ParseULID parses the 26 characters text form of a ulid, case-insensitively
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 {
+		return u, errors.New("invalid ulid length")
+	}
+	s = strings.ToUpper(s)
+	if s[0] > '7' {
+		return u, errors.New("ulid overflows 128 bits")
+	}
+
+	// 26 characters hold 130 bits, the first 2 bits are always zero
+	var acc uint
+	bits := 0
+	k := 15
+	for i := len(s) - 1; i >= 0; i-- {
+		v := strings.IndexByte(crockford, s[i])
+		if v < 0 {
+			return u, errors.New("invalid ulid character")
+		}
+		acc |= uint(v) << bits
+		bits += 5
+		for bits >= 8 && k >= 0 {
+			u[k] = byte(acc)
+			acc >>= 8
+			bits -= 8
+			k--
+		}
+	}
+	return u, nil
+}
+
+// String returns the 26 characters text form of the ulid
+func (u ULID) String() string {
+	out := make([]byte, 26)
+	var acc uint
+	bits := 0
+	k := 25
+	for i := 15; i >= 0; i-- {
+		acc |= uint(u[i]) << bits
+		bits += 8
+		for bits >= 5 {
+			out[k] = crockford[acc&0x1f]
+			acc >>= 5
+			bits -= 5
+			k--
+		}
+	}
+	out[0] = crockford[acc&0x1f]
+	return string(out)
+}
+
+// Time returns the timestamp of the ulid
+func (u ULID) Time() time.Time {
+	var b [8]byte
+	copy(b[2:], u[:6])
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(b[:])))
+}
+
+func putULIDTime(u *ULID, t time.Time) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(t.UnixMilli()))
+	copy(u[:6], b[2:])
+}
+
+// NanoIDAlphabet is the default url-safe alphabet of NanoID
+const NanoIDAlphabet = "useandom-26T198340PX75pxJACKVERYMINDBUSHWOLF_GQZbfghjklqvwyzrict"
+
+// NanoID returns a random id of size characters from the alphabet,
+// the alphabet defaults to NanoIDAlphabet and the size to 21.
+func (g *Generator) NanoID(alphabet string, size int) (string, error) {
+	if alphabet == "" {
+		alphabet = NanoIDAlphabet
+	}
+	if size == 0 {
+		size = 21
+	}
+	if size < 0 {
+		return "", errors.New("invalid nanoid size")
+	}
+	return g.String(alphabet, size)
+}
@@ -0,0 +1,143 @@
+package random
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+var (
+	effOnce  sync.Once
+	effWords []string
+)
+
+// EFFWordlist returns the 7776 words of the EFF large wordlist
+func EFFWordlist() []string {
+	effOnce.Do(func() {
+		effWords, _ = LoadWordlist(strings.NewReader(effLargeWordlist))
+	})
+	return append([]string{}, effWords...)
+}
+
+// LoadWordlist reads one word per line, a leading dice roll column such as "11111\t" is skipped.
+// Words must be unique so that every word adds the same entropy.
+func LoadWordlist(r io.Reader) ([]string, error) {
+	var words []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		word := fields[len(fields)-1]
+		if seen[word] {
+			return nil, errors.New("duplicate word in wordlist: " + word)
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(words) < 2 {
+		return nil, errors.New("wordlist requires at least 2 words")
+	}
+	return words, nil
+}
+
+// PassphraseOptions describes the passphrases generated by Passphrase
+type PassphraseOptions struct {
+	Words       int      // number of words, defaults to 6
+	Separator   string   // defaults to "-" unless NoSeparator is set
+	NoSeparator bool     // joins the words without a separator
+	Capitalize  bool     // capitalizes the first letter of every word
+	Digits      int      // number of distinct words that get a random digit appended
+	Wordlist    []string // defaults to the EFF large wordlist
+}
+
+// Passphrase generates a diceware-style passphrase.
+// The entropy counts the distinct words and the digits, capitalization and separators don't add any.
+// Without a separator some words may run together ambiguously, so the entropy is an upper bound.
+func (g *Generator) Passphrase(opts PassphraseOptions) (Secret, error) {
+	if opts.Words == 0 {
+		opts.Words = 6
+	}
+	if opts.NoSeparator {
+		opts.Separator = ""
+	} else if opts.Separator == "" {
+		opts.Separator = "-"
+	}
+	if opts.Wordlist == nil {
+		opts.Wordlist = EFFWordlist()
+	}
+	if opts.Words < 1 || opts.Words > 1024 {
+		return Secret{}, errors.New("passphrase words must be between 1 and 1024")
+	}
+	if opts.Digits < 0 || opts.Digits > opts.Words {
+		return Secret{}, errors.New("passphrase digits must be between 0 and the number of words")
+	}
+
+	// capitalization may merge words such as "apple" and "Apple",
+	// so the words are picked from the distinct normalized words
+	wordlist := make([]string, 0, len(opts.Wordlist))
+	seen := map[string]bool{}
+	for _, word := range opts.Wordlist {
+		if opts.Capitalize {
+			r, size := utf8.DecodeRuneInString(word)
+			word = string(unicode.ToUpper(r)) + word[size:]
+		}
+		if !seen[word] {
+			seen[word] = true
+			wordlist = append(wordlist, word)
+		}
+	}
+	if len(wordlist) < 2 {
+		return Secret{}, errors.New("wordlist requires at least 2 distinct words")
+	}
+
+	words := make([]string, opts.Words)
+	for i := range words {
+		k, err := g.Intn(len(wordlist))
+		if err != nil {
+			return Secret{}, err
+		}
+		words[i] = wordlist[k]
+	}
+
+	// the first Digits indexes of a random permutation are the words that get a digit
+	perm := make([]int, opts.Words)
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := 0; i < opts.Digits; i++ {
+		k, err := g.Intn(opts.Words - i)
+		if err != nil {
+			return Secret{}, err
+		}
+		perm[i], perm[i+k] = perm[i+k], perm[i]
+		d, err := g.Intn(10)
+		if err != nil {
+			return Secret{}, err
+		}
+		words[perm[i]] += string(rune('0' + d))
+	}
+
+	entropy := float64(opts.Words) * math.Log2(float64(len(wordlist)))
+	if opts.Digits > 0 {
+		positions := new(big.Int).Binomial(int64(opts.Words), int64(opts.Digits))
+		entropy += log2(positions) + float64(opts.Digits)*math.Log2(10)
+	}
+
+	return Secret{Text: strings.Join(words, opts.Separator), Entropy: entropy}, nil
+}
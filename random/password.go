@@ -0,0 +1,162 @@
+package random
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Character classes of PasswordPolicy
+const (
+	Lowercase = "abcdefghijklmnopqrstuvwxyz"
+	Uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	Digits    = "0123456789"
+	Symbols   = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+	Ambiguous = "0Oo1Il|"
+)
+
+// Class is a set of characters and the minimum number of them in a password
+type Class struct {
+	Chars string
+	Min   int
+}
+
+// PasswordPolicy describes the passwords generated by Password
+type PasswordPolicy struct {
+	Length           int     // defaults to 16
+	Classes          []Class // defaults to lowercase, uppercase, digits and symbols with at least one of each
+	ExcludeAmbiguous bool    // removes Ambiguous characters from all classes
+}
+
+// Secret is a generated secret and its entropy in bits
+type Secret struct {
+	Text    string
+	Entropy float64
+}
+
+// Password generates a password that satisfies the policy.
+// The password is drawn uniformly from all passwords satisfying the policy,
+// so the entropy is exactly log2 of their number.
+func (g *Generator) Password(policy PasswordPolicy) (Secret, error) {
+	if policy.Length == 0 {
+		policy.Length = 16
+	}
+	if policy.Length < 0 || policy.Length > 1024 {
+		return Secret{}, errors.New("password length must be between 1 and 1024")
+	}
+	if len(policy.Classes) == 0 {
+		policy.Classes = []Class{{Lowercase, 1}, {Uppercase, 1}, {Digits, 1}, {Symbols, 1}}
+	}
+
+	classes, err := normalizeClasses(policy.Classes, policy.ExcludeAmbiguous)
+	if err != nil {
+		return Secret{}, err
+	}
+	mins := 0
+	for _, c := range policy.Classes {
+		mins += c.Min
+	}
+	if mins > policy.Length {
+		return Secret{}, errors.New("minimum counts exceed the password length")
+	}
+
+	// ways[i][j] is the number of strings of length j over the first i classes satisfying their minimums
+	length := policy.Length
+	ways := make([][]*big.Int, len(classes)+1)
+	ways[0] = make([]*big.Int, length+1)
+	for j := range ways[0] {
+		ways[0][j] = new(big.Int)
+	}
+	ways[0][0].SetInt64(1)
+	for i, c := range classes {
+		ways[i+1] = make([]*big.Int, length+1)
+		for j := 0; j <= length; j++ {
+			ways[i+1][j] = new(big.Int)
+			for n := policy.Classes[i].Min; n <= j; n++ {
+				ways[i+1][j].Add(ways[i+1][j], weight(c, n, j, ways[i][j-n]))
+			}
+		}
+	}
+	total := ways[len(classes)][length]
+
+	// draw how many characters each class gets, weighted by the number of passwords with those counts
+	chars := make([]rune, 0, length)
+	j := length
+	for i := len(classes) - 1; i >= 0; i-- {
+		r, err := g.Int(ways[i+1][j])
+		if err != nil {
+			return Secret{}, err
+		}
+		n := policy.Classes[i].Min
+		for ; n < j; n++ {
+			w := weight(classes[i], n, j, ways[i][j-n])
+			if r.Cmp(w) < 0 {
+				break
+			}
+			r.Sub(r, w)
+		}
+		for k := 0; k < n; k++ {
+			x, err := g.Intn(len(classes[i]))
+			if err != nil {
+				return Secret{}, err
+			}
+			chars = append(chars, classes[i][x])
+		}
+		j -= n
+	}
+
+	// every arrangement of the drawn characters is equally likely
+	for i := len(chars) - 1; i > 0; i-- {
+		k, err := g.Intn(i + 1)
+		if err != nil {
+			return Secret{}, err
+		}
+		chars[i], chars[k] = chars[k], chars[i]
+	}
+
+	return Secret{Text: string(chars), Entropy: log2(total)}, nil
+}
+
+// normalizeClasses removes duplicate and excluded characters, classes must not overlap
+func normalizeClasses(classes []Class, excludeAmbiguous bool) ([][]rune, error) {
+	seen := map[rune]bool{}
+	out := make([][]rune, len(classes))
+	for i, c := range classes {
+		if c.Min < 0 {
+			return nil, errors.New("minimum count must not be negative")
+		}
+		inClass := map[rune]bool{}
+		for _, r := range c.Chars {
+			if inClass[r] || (excludeAmbiguous && strings.ContainsRune(Ambiguous, r)) {
+				continue
+			}
+			if seen[r] {
+				return nil, errors.New("character classes must not overlap")
+			}
+			inClass[r] = true
+			seen[r] = true
+			out[i] = append(out[i], r)
+		}
+		if len(out[i]) == 0 {
+			return nil, errors.New("character class is empty")
+		}
+	}
+	return out, nil
+}
+
+// weight returns the number of strings of length j having exactly n characters of class c
+// and the other j-n characters from one of rest strings.
+func weight(c []rune, n, j int, rest *big.Int) *big.Int {
+	w := new(big.Int).Binomial(int64(j), int64(n))
+	w.Mul(w, new(big.Int).Exp(big.NewInt(int64(len(c))), big.NewInt(int64(n)), nil))
+	return w.Mul(w, rest)
+}
+
+// log2 returns log2(x) for a positive x that may not fit in a float64
+func log2(x *big.Int) float64 {
+	mant := new(big.Float)
+	exp := new(big.Float).SetInt(x).MantExp(mant)
+	m, _ := mant.Float64()
+	return float64(exp) + math.Log2(m)
+}
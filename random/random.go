@@ -3,18 +3,103 @@ package random
 
 import (
 	"crypto/rand"
-	"encoding/ascii85"
-	"encoding/base64"
-	"encoding/hex"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"strings"
+	"math/big"
+	"os"
+	"unicode/utf8"
 )
 
+// Alphabets of the text helpers
+const (
+	HexAlphabet       = "0123456789abcdef"
+	Base64URLAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+	Ascii85Alphabet   = "!\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstu"
+)
+
+// Bytes returns n random bytes
+func (g *Generator) Bytes(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, errors.New("invalid argument to Bytes")
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(g.reader, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Int returns a uniform random value in [0, max), it returns an error if max <= 0
+func (g *Generator) Int(max *big.Int) (*big.Int, error) {
+	if max == nil || max.Sign() <= 0 {
+		return nil, errors.New("invalid argument to Int")
+	}
+	return rand.Int(g.reader, max)
+}
+
+// Intn returns a uniform random int in [0, n) using rejection sampling, it returns an error if n <= 0
+func (g *Generator) Intn(n int) (int, error) {
+	if n <= 0 {
+		return 0, errors.New("invalid argument to Intn")
+	}
+	max := uint64(n)
+	// values at or above limit are rejected so every residue is equally likely
+	limit := -(-max % max) // 2^64 - (2^64 mod n), 0 means 2^64
+	var buf [8]byte
+	for {
+		if _, err := io.ReadFull(g.reader, buf[:]); err != nil {
+			return 0, err
+		}
+		v := binary.BigEndian.Uint64(buf[:])
+		if limit == 0 || v < limit {
+			return int(v % max), nil
+		}
+	}
+}
+
+// String returns n characters drawn uniformly from the alphabet
+func (g *Generator) String(alphabet string, n int) (string, error) {
+	if n < 0 {
+		return "", errors.New("invalid argument to String")
+	}
+	chars := []rune(alphabet)
+	if len(chars) == 0 || !utf8.ValidString(alphabet) {
+		return "", errors.New("invalid alphabet")
+	}
+	out := make([]rune, n)
+	for i := range out {
+		k, err := g.Intn(len(chars))
+		if err != nil {
+			return "", err
+		}
+		out[i] = chars[k]
+	}
+	return string(out), nil
+}
+
+// Hex returns n random lowercase hex characters
+func (g *Generator) Hex(n int) (string, error) {
+	return g.String(HexAlphabet, n)
+}
+
+// Base64URL returns n random characters of the url-safe base64 alphabet
+func (g *Generator) Base64URL(n int) (string, error) {
+	return g.String(Base64URLAlphabet, n)
+}
+
+// Ascii85 returns n random characters of the ascii85 alphabet
+func (g *Generator) Ascii85(n int) (string, error) {
+	return g.String(Ascii85Alphabet, n)
+}
+
 // NewBytes generates random bytes.
+//
+// Deprecated: NewBytes exits the program on failure, use Bytes instead.
 func NewBytes(len int) []byte {
-	b := make([]byte, len)
-	_, err := rand.Read(b)
+	b, err := Bytes(len)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -22,34 +107,37 @@ func NewBytes(len int) []byte {
 }
 
 // NewTexts generates random texts and prints them out in specific numbers of characters.
-func NewTexts() {
-	b := NewBytes(128)
-	s := hex.EncodeToString(b)
-	fmt.Println("hex")
-	print(s)
-
-	b = NewBytes(128)
-	s = base64.StdEncoding.EncodeToString(b)
-	s = strings.ReplaceAll(s, "+", "")
-	s = strings.ReplaceAll(s, "/", "")
-	s = strings.ReplaceAll(s, "=", "")
-	fmt.Println("base64")
-	print(s)
-
-	b = NewBytes(128)
-	b2 := make([]byte, len(b)*2)
-	ascii85.Encode(b2, b)
-	s = string(b2)
-	fmt.Println("ascii")
-	print(s)
-}
-
-func print(s string) {
-	if len(s) < 112 {
-		return
-	}
-	fmt.Println("16", s[0:16])
-	fmt.Println("32", s[16:48])
-	fmt.Println("64", s[48:112])
-	fmt.Println()
+func NewTexts() error {
+	return WriteTexts(os.Stdout)
+}
+
+// WriteTexts writes random hex, base64url and ascii85 texts of 16, 32 and 64 characters to w.
+func WriteTexts(w io.Writer) error {
+	texts := []struct {
+		name string
+		fn   func(int) (string, error)
+	}{
+		{"hex", Hex},
+		{"base64url", Base64URL},
+		{"ascii85", Ascii85},
+	}
+
+	for _, t := range texts {
+		if _, err := fmt.Fprintln(w, t.name); err != nil {
+			return err
+		}
+		for _, n := range []int{16, 32, 64} {
+			s, err := t.fn(n)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(w, n, s); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -1,11 +1,768 @@
 package random_test
 
 import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/keng42/go/cnigma/rsa"
+	"github.com/keng42/go/cnigma/rsa/types"
 	"github.com/keng42/go/random"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRandomGen(t *testing.T) {
-	random.NewTexts()
+	require.Nil(t, random.NewTexts())
+
+	var sb strings.Builder
+	require.Nil(t, random.WriteTexts(&sb))
+	lines := strings.Split(sb.String(), "\n")
+	require.Equal(t, "hex", lines[0])
+	require.Len(t, lines[3], len("64 ")+64)
+	require.Equal(t, "base64url", lines[5])
+	require.Equal(t, "ascii85", lines[10])
+}
+
+func TestPrimitives(t *testing.T) {
+	b, err := random.Bytes(32)
+	require.Nil(t, err)
+	require.Len(t, b, 32)
+	_, err = random.Bytes(-1)
+	require.NotNil(t, err)
+
+	v, err := random.Int(big.NewInt(1000))
+	require.Nil(t, err)
+	require.True(t, v.Sign() >= 0 && v.Cmp(big.NewInt(1000)) < 0)
+	_, err = random.Int(big.NewInt(0))
+	require.NotNil(t, err)
+
+	_, err = random.Intn(0)
+	require.NotNil(t, err)
+	n, err := random.Intn(1)
+	require.Nil(t, err)
+	require.Equal(t, 0, n)
+
+	// every value of a range that doesn't divide 2^64 is hit about equally often
+	counts := make([]int, 6)
+	for i := 0; i < 60000; i++ {
+		n, err := random.Intn(6)
+		require.Nil(t, err)
+		counts[n]++
+	}
+	for _, c := range counts {
+		require.InDelta(t, 10000, c, 500)
+	}
+
+	s, err := random.Hex(40)
+	require.Nil(t, err)
+	require.Len(t, s, 40)
+	require.Equal(t, "", strings.Trim(s, random.HexAlphabet))
+
+	s, err = random.Base64URL(40)
+	require.Nil(t, err)
+	require.Len(t, s, 40)
+	require.Equal(t, "", strings.Trim(s, random.Base64URLAlphabet))
+
+	s, err = random.Ascii85(40)
+	require.Nil(t, err)
+	require.Len(t, s, 40)
+	require.Equal(t, "", strings.Trim(s, random.Ascii85Alphabet))
+	require.Len(t, random.Ascii85Alphabet, 85)
+
+	s, err = random.String("αβ", 10)
+	require.Nil(t, err)
+	require.Len(t, []rune(s), 10)
+	_, err = random.String("", 10)
+	require.NotNil(t, err)
+}
+
+func TestPassword(t *testing.T) {
+	s, err := random.Password(random.PasswordPolicy{})
+	require.Nil(t, err)
+	require.Len(t, s.Text, 16)
+	require.True(t, strings.ContainsAny(s.Text, random.Lowercase))
+	require.True(t, strings.ContainsAny(s.Text, random.Uppercase))
+	require.True(t, strings.ContainsAny(s.Text, random.Digits))
+	require.True(t, strings.ContainsAny(s.Text, random.Symbols))
+	require.Greater(t, s.Entropy, 100.0)
+	require.Less(t, s.Entropy, 16*math.Log2(90))
+
+	s, err = random.Password(random.PasswordPolicy{
+		Length:  10,
+		Classes: []random.Class{{Chars: random.Digits}},
+	})
+	require.Nil(t, err)
+	require.Len(t, s.Text, 10)
+	require.InDelta(t, 10*math.Log2(10), s.Entropy, 1e-9)
+
+	for i := 0; i < 20; i++ {
+		s, err = random.Password(random.PasswordPolicy{
+			Length:           32,
+			Classes:          []random.Class{{random.Lowercase, 3}, {random.Uppercase, 3}, {random.Digits, 20}},
+			ExcludeAmbiguous: true,
+		})
+		require.Nil(t, err)
+		require.False(t, strings.ContainsAny(s.Text, random.Ambiguous))
+		digits := 0
+		for _, r := range s.Text {
+			if strings.ContainsRune(random.Digits, r) {
+				digits++
+			}
+		}
+		require.GreaterOrEqual(t, digits, 20)
+	}
+
+	// custom alphabets
+	s, err = random.Password(random.PasswordPolicy{
+		Length:  8,
+		Classes: []random.Class{{Chars: "αβγ", Min: 8}},
+	})
+	require.Nil(t, err)
+	require.Len(t, []rune(s.Text), 8)
+
+	invalid := []random.PasswordPolicy{
+		{Length: -1},
+		{Length: 2, Classes: []random.Class{{"ab", 2}, {"cd", 1}}},
+		{Length: 8, Classes: []random.Class{{"abc", 1}, {"cde", 1}}},
+		{Length: 8, Classes: []random.Class{{"0O", 1}}, ExcludeAmbiguous: true},
+		{Length: 8, Classes: []random.Class{{"ab", -1}}},
+	}
+	for _, policy := range invalid {
+		_, err = random.Password(policy)
+		require.NotNil(t, err)
+	}
+}
+
+func TestPasswordUniform(t *testing.T) {
+	// 18 strings of length 3 over {a,b,c} contain a 'c' and an 'a' or 'b'
+	policy := random.PasswordPolicy{Length: 3, Classes: []random.Class{{"ab", 1}, {"c", 1}}}
+	counts := map[string]int{}
+	for i := 0; i < 18000; i++ {
+		s, err := random.Password(policy)
+		require.Nil(t, err)
+		require.InDelta(t, math.Log2(18), s.Entropy, 1e-9)
+		counts[s.Text]++
+	}
+	require.Len(t, counts, 18)
+	for text, n := range counts {
+		require.InDelta(t, 1000, n, 200, text)
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	words := random.EFFWordlist()
+	require.Len(t, words, 7776)
+	require.Equal(t, "abacus", words[0])
+	require.Equal(t, "zoom", words[7775])
+
+	s, err := random.Passphrase(random.PassphraseOptions{})
+	require.Nil(t, err)
+	require.Len(t, strings.Split(s.Text, "-"), 6)
+	require.InDelta(t, 6*math.Log2(7776), s.Entropy, 1e-9)
+
+	s, err = random.Passphrase(random.PassphraseOptions{Words: 4, Separator: " ", Capitalize: true, Digits: 2})
+	require.Nil(t, err)
+	parts := strings.Split(s.Text, " ")
+	require.Len(t, parts, 4)
+	withDigit := 0
+	for _, p := range parts {
+		require.True(t, p[0] >= 'A' && p[0] <= 'Z')
+		if strings.ContainsAny(p, random.Digits) {
+			withDigit++
+		}
+	}
+	require.Equal(t, 2, withDigit)
+	require.InDelta(t, 4*math.Log2(7776)+math.Log2(6)+2*math.Log2(10), s.Entropy, 1e-9)
+
+	custom, err := random.LoadWordlist(strings.NewReader("1\tred\n2\tgreen\n\nblue\n"))
+	require.Nil(t, err)
+	require.Equal(t, []string{"red", "green", "blue"}, custom)
+	s, err = random.Passphrase(random.PassphraseOptions{Words: 3, Wordlist: custom})
+	require.Nil(t, err)
+	for _, p := range strings.Split(s.Text, "-") {
+		require.Contains(t, custom, p)
+	}
+	require.InDelta(t, 3*math.Log2(3), s.Entropy, 1e-9)
+
+	s, err = random.Passphrase(random.PassphraseOptions{Words: 5, NoSeparator: true, Wordlist: custom})
+	require.Nil(t, err)
+	require.NotContains(t, s.Text, "-")
+	require.Regexp(t, "^(red|green|blue){5}$", s.Text)
+
+	// "apple" and "Apple" are the same word once capitalized
+	s, err = random.Passphrase(random.PassphraseOptions{Words: 2, Capitalize: true, Wordlist: []string{"apple", "Apple", "pear"}})
+	require.Nil(t, err)
+	require.InDelta(t, 2*math.Log2(2), s.Entropy, 1e-9)
+	_, err = random.Passphrase(random.PassphraseOptions{Capitalize: true, Wordlist: []string{"apple", "Apple"}})
+	require.NotNil(t, err)
+
+	_, err = random.LoadWordlist(strings.NewReader("red\nred\n"))
+	require.NotNil(t, err)
+	_, err = random.Passphrase(random.PassphraseOptions{Words: 3, Digits: 4})
+	require.NotNil(t, err)
+	_, err = random.Passphrase(random.PassphraseOptions{Wordlist: []string{"one"}})
+	require.NotNil(t, err)
+}
+
+func TestUUID(t *testing.T) {
+	u, err := random.UUIDv4()
+	require.Nil(t, err)
+	require.Equal(t, 4, u.Version())
+	require.Equal(t, byte(0x80), u[8]&0xc0)
+
+	parsed, err := random.ParseUUID(u.String())
+	require.Nil(t, err)
+	require.Equal(t, u, parsed)
+
+	// RFC 9562 appendix A.6
+	u, err = random.ParseUUID("017F22E2-79B0-7CC3-98C4-DC0C0C07398F")
+	require.Nil(t, err)
+	require.Equal(t, 7, u.Version())
+	require.Equal(t, int64(0x017F22E279B0), u.Time().UnixMilli())
+	require.Equal(t, "017f22e2-79b0-7cc3-98c4-dc0c0c07398f", u.String())
+
+	_, err = random.ParseUUID("urn:uuid:017f22e2-79b0-7cc3-98c4-dc0c0c07398f")
+	require.Nil(t, err)
+	_, err = random.ParseUUID("017f22e279b07cc398c4dc0c0c07398f")
+	require.NotNil(t, err)
+	_, err = random.ParseUUID("017f22e2-79b0-7cc3-98c4-dc0c0c07398g")
+	require.NotNil(t, err)
+
+	before := time.Now().UnixMilli()
+	prev, err := random.UUIDv7()
+	require.Nil(t, err)
+	require.Equal(t, 7, prev.Version())
+	require.Equal(t, byte(0x80), prev[8]&0xc0)
+	require.GreaterOrEqual(t, prev.Time().UnixMilli(), before)
+	require.LessOrEqual(t, prev.Time().UnixMilli(), time.Now().UnixMilli())
+	for i := 0; i < 1000; i++ {
+		u, err := random.UUIDv7()
+		require.Nil(t, err)
+		require.Less(t, prev.String(), u.String())
+		prev = u
+	}
+}
+
+func TestULID(t *testing.T) {
+	u, err := random.ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	require.Nil(t, err)
+	require.Equal(t, int64(1469922850259), u.Time().UnixMilli())
+	require.Equal(t, "01ARZ3NDEKTSV4RRFFQ69G5FAV", u.String())
+
+	lower, err := random.ParseULID("01arz3ndektsv4rrffq69g5fav")
+	require.Nil(t, err)
+	require.Equal(t, u, lower)
+
+	max, err := random.ParseULID("7ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	require.Nil(t, err)
+	require.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", max.String())
+	_, err = random.ParseULID("8ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	require.NotNil(t, err)
+	_, err = random.ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAU")
+	require.NotNil(t, err)
+
+	before := time.Now().UnixMilli()
+	u, err = random.NewULID()
+	require.Nil(t, err)
+	require.Len(t, u.String(), 26)
+	require.GreaterOrEqual(t, u.Time().UnixMilli(), before)
+
+	// concurrent monotonic ulids are unique and increasing per goroutine
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := map[random.ULID]bool{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var prev random.ULID
+			for i := 0; i < 500; i++ {
+				u, err := random.NewMonotonicULID()
+				require.Nil(t, err)
+				require.Less(t, prev.String(), u.String())
+				prev = u
+				mu.Lock()
+				seen[u] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, seen, 8*500)
+}
+
+func TestNanoID(t *testing.T) {
+	id, err := random.NanoID("", 0)
+	require.Nil(t, err)
+	require.Len(t, id, 21)
+	require.Equal(t, "", strings.Trim(id, random.NanoIDAlphabet))
+
+	id, err = random.NanoID("abc", 10)
+	require.Nil(t, err)
+	require.Len(t, id, 10)
+	require.Equal(t, "", strings.Trim(id, "abc"))
+
+	_, err = random.NanoID("", -1)
+	require.NotNil(t, err)
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.Nil(t, err)
+	return b
+}
+
+// ACVP CTR_DRBG AES-256 without derivation function, with prediction resistance disabled
+// https://github.com/usnistgov/ACVP-Server/blob/fb44dce/gen-val/json-files/ctrDRBG-1.0/prompt.json#L4447-L4482
+func TestDRBGVector(t *testing.T) {
+	entropy := decodeHex(t, "9FCBB4CCC0135C484BDED061DA9FD70748682FE84166B97FF53F9AA1909B2E95D3D529C0F453B3AC575D12AA441CC5CD")
+	personalization := decodeHex(t, "2C9FED0B39556CDBE699EBCA2A0EC7EECB287E8744475050C572FA8AE9ED0A4A7D6F1CABF1C4278532FB20AF7D64BD32")
+	reseedEntropy := decodeHex(t, "913C0DA19B010EDDD55A7A4F3F713EEF5B1534D34360A7EC376AE71A6B340043CC7726F762CB853453F399B3A645062A")
+	reseedAdditional := decodeHex(t, "2D9D4EC141A22E6CD2F6EE4F6719CF6BDF95CFE50B8D5EA6C87D38B4B872706FFF80B0380BB90E9C42D11D6526E56C29")
+	additional1 := decodeHex(t, "A642F06D327828F3E84564A3E37D60C157073B95864CA07981B0189668A0D978CD5DC68F06801CEFF0DC839A312B028E")
+	additional2 := decodeHex(t, "9DB14BABFA9107C88BA92073C0B4A65E89147EA06D74B894142979482F452915B35B5636F9B8A951759735ADE7C8D5D1")
+	returnedBits := decodeHex(t, ""+
+		"F10C645683FF0131254052ED4C698122B46B563654C29D728AC191CA4AAEFE649EEFE4C6FC33B25BB739294DD5CF5780"+
+		"99F856C98D98000CBF971F1E6EA900822FF8C110118F6520471744D3F8A3F5C7D568494240E57F5488AF9C9F9F4E7322"+
+		"F56CCD843C0DBFCE9170C02E205389420527F23EDB3369D9FCC5E34901B5BA4EB71B973FC7982FFE0899FF7FE53EE0C4"+
+		"F51A3EF93EF9C6D4D279DD7536F8776BE94AAA05E89EF6E6AEE8832B4B42FFCA5FB91EC0273F9EF945865512889B0C5E"+
+		"E141D1B38DF827D2A694835561628C6F9B093A01A835F07ADBB9E03FEBF93389E8F3B86E1E0ABF1F9958FA286AD99528"+
+		"9C2F606D1A9043A166C1AFE8D00769C712650819C9068A4BD22717C98338395A7BA6E95B5178BFBF4EFB0F05A91713BA"+
+		"8BF2127A6BA1EDFA6D1CAB05C03EE0D2AFE1DA4EB8F2C579EC872FF4B602027EF4BDCF2F4B01423F8E600A13D7CACB6A"+
+		"B83263BA58F907694AF614A6724FD0E4C627A0D91DDC6716C697FACE6F4808A4F37B731DE4E0CD4766CEADAAAF479925"+
+		"05299C72AC1A6E9A8335B8D7E501B3841188D0DA4DE5267674444DC2B0CF9F010756FA865A25CA3F1B24C34E845B2259"+
+		"926B6A867A7684DE68A6137C4FB0F47A2E54AE9E6455BEBA0B0A9629644FE9E378EE95386443BA977124FFD1192E9F46"+
+		"0684C7B09FA99F5F93F04F56FD7955E042187887CE696F1934017E458B16B5C9")
+
+	d, err := random.NewSeededDRBG(entropy, personalization)
+	require.Nil(t, err)
+	require.Nil(t, d.Reseed(reseedEntropy, reseedAdditional))
+
+	buf := make([]byte, len(returnedBits))
+	require.Nil(t, d.Generate(buf, additional1))
+	require.Nil(t, d.Generate(buf, additional2))
+	require.Equal(t, returnedBits, buf)
+}
+
+// known answer test of the CTR_DRBG in the Go standard library
+func TestDRBGKnownAnswer(t *testing.T) {
+	seq := func(from byte) []byte {
+		b := make([]byte, random.DRBGSeedSize)
+		for i := range b {
+			b[i] = from + byte(i)
+		}
+		return b
+	}
+
+	d, err := random.NewSeededDRBG(seq(0x01), nil)
+	require.Nil(t, err)
+	require.Nil(t, d.Reseed(seq(0x31), seq(0x61)))
+
+	buf := make([]byte, 32)
+	require.Nil(t, d.Generate(buf, seq(0x61)))
+	require.Equal(t, "6e6e479d24f86a3b7787a8f8186d985a53bebeeddeab9228f0f4ac6e10bf0193", hex.EncodeToString(buf))
+}
+
+func TestDRBG(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, random.DRBGSeedSize)
+
+	// the same seed gives the same values through every helper
+	values := func(personalization string) []string {
+		d, err := random.NewSeededDRBG(seed, []byte(personalization))
+		require.Nil(t, err)
+		g := random.NewGenerator(d)
+
+		h, err := g.Hex(32)
+		require.Nil(t, err)
+		n, err := g.Intn(1000)
+		require.Nil(t, err)
+		password, err := g.Password(random.PasswordPolicy{})
+		require.Nil(t, err)
+		passphrase, err := g.Passphrase(random.PassphraseOptions{})
+		require.Nil(t, err)
+		u, err := g.UUIDv4()
+		require.Nil(t, err)
+		id, err := g.NanoID("", 0)
+		require.Nil(t, err)
+		return []string{h, fmt.Sprint(n), password.Text, passphrase.Text, u.String(), id}
+	}
+	require.Equal(t, values("fixtures"), values("fixtures"))
+	require.NotEqual(t, values("fixtures"), values("simulation"))
+
+	// reads longer than one request
+	d, err := random.NewSeededDRBG(seed, nil)
+	require.Nil(t, err)
+	long := make([]byte, 200000)
+	n, err := io.ReadFull(d, long)
+	require.Nil(t, err)
+	require.Equal(t, len(long), n)
+	require.NotEqual(t, long[:16], long[len(long)-16:])
+
+	// seeded drbgs can't reseed on their own
+	require.NotNil(t, d.Reseed(nil, nil))
+	d.PredictionResistance = true
+	_, err = d.Read(make([]byte, 16))
+	require.NotNil(t, err)
+
+	// drbgs with an entropy source reseed before each request with prediction resistance
+	d, err = random.NewDRBG(nil, []byte("server"))
+	require.Nil(t, err)
+	d.PredictionResistance = true
+	b, err := random.NewGenerator(d).Bytes(64)
+	require.Nil(t, err)
+	require.Len(t, b, 64)
+	require.Nil(t, d.Reseed(nil, []byte("additional")))
+
+	_, err = random.NewSeededDRBG(seed[:32], nil)
+	require.NotNil(t, err)
+	_, err = random.NewSeededDRBG(seed, make([]byte, 49))
+	require.NotNil(t, err)
+	_, err = random.NewDRBG(bytes.NewReader(seed[:10]), nil)
+	require.NotNil(t, err)
+}
+
+// readerFunc returns bytes from next forever
+type readerFunc func() byte
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = f()
+	}
+	return len(p), nil
+}
+
+func TestHealthReader(t *testing.T) {
+	h, err := random.NewHealthReader(crand.Reader, random.HealthConfig{})
+	require.Nil(t, err)
+
+	stats := h.Stats()
+	require.Equal(t, 6, stats.RCTCutoff)
+	require.Equal(t, 19, stats.APTCutoff)
+	require.Equal(t, uint64(1024), stats.Samples)
+
+	g := random.NewGenerator(h)
+	b, err := g.Bytes(1 << 20)
+	require.Nil(t, err)
+	require.Len(t, b, 1<<20)
+
+	stats = h.Stats()
+	require.Equal(t, uint64(1024+1<<20), stats.Samples)
+	require.Equal(t, stats.Samples/512, stats.Windows)
+	require.Zero(t, stats.RCTFailures)
+	require.Zero(t, stats.APTFailures)
+	require.Less(t, stats.MaxRun, stats.RCTCutoff)
+	require.Less(t, stats.MaxCount, stats.APTCutoff)
+
+	// a stuck source fails the startup test
+	_, err = random.NewHealthReader(readerFunc(func() byte { return 7 }), random.HealthConfig{})
+	require.ErrorIs(t, err, random.ErrHealthTest)
+
+	// every other byte is zero, which never repeats but is far too frequent
+	var i byte
+	biased := readerFunc(func() byte {
+		i++
+		if i%2 == 1 {
+			return 0
+		}
+		return i
+	})
+	_, err = random.NewHealthReader(biased, random.HealthConfig{})
+	require.ErrorIs(t, err, random.ErrHealthTest)
+
+	// a source that fails later makes the reader fail for good
+	n := 0
+	failing := readerFunc(func() byte {
+		n++
+		if n > 4096 {
+			return 0
+		}
+		return byte(n*37 + n/256)
+	})
+	h, err = random.NewHealthReader(failing, random.HealthConfig{MinEntropy: 4})
+	require.Nil(t, err)
+	require.Equal(t, 11, h.Stats().RCTCutoff)
+	buf := make([]byte, 4096)
+	_, err = io.ReadFull(h, buf)
+	require.ErrorIs(t, err, random.ErrHealthTest)
+	require.Equal(t, make([]byte, 4096), buf)
+	_, err = h.Read(buf[:1])
+	require.ErrorIs(t, err, random.ErrHealthTest)
+	stats = h.Stats()
+	require.Equal(t, uint64(1), stats.RCTFailures)
+
+	_, err = random.NewHealthReader(crand.Reader, random.HealthConfig{MinEntropy: 9})
+	require.NotNil(t, err)
+}
+
+// requireUniform checks that every count is within 6 standard deviations of its expectation
+func requireUniform(t *testing.T, counts map[string]int, trials int, probs map[string]float64) {
+	require.Len(t, counts, len(probs))
+	for k, p := range probs {
+		expected := float64(trials) * p
+		sigma := math.Sqrt(expected * (1 - p))
+		require.InDelta(t, expected, counts[k], 6*sigma, k)
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	const trials = 60000
+	counts := map[string]int{}
+	for i := 0; i < trials; i++ {
+		s := []string{"a", "b", "c"}
+		require.Nil(t, random.Shuffle(s))
+		counts[strings.Join(s, "")]++
+	}
+	probs := map[string]float64{}
+	for _, k := range []string{"abc", "acb", "bac", "bca", "cab", "cba"} {
+		probs[k] = 1.0 / 6
+	}
+	requireUniform(t, counts, trials, probs)
+
+	p, err := random.Perm(10)
+	require.Nil(t, err)
+	require.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, p)
+	_, err = random.Perm(-1)
+	require.NotNil(t, err)
+	require.Nil(t, random.Shuffle([]int{}))
+}
+
+func TestChoiceSample(t *testing.T) {
+	const trials = 50000
+	items := []string{"a", "b", "c", "d", "e"}
+
+	counts := map[string]int{}
+	for i := 0; i < trials; i++ {
+		c, err := random.Choice(items)
+		require.Nil(t, err)
+		counts[c]++
+	}
+	probs := map[string]float64{}
+	for _, k := range items {
+		probs[k] = 0.2
+	}
+	requireUniform(t, counts, trials, probs)
+
+	// every ordered pair of distinct elements is equally likely
+	counts = map[string]int{}
+	for i := 0; i < trials; i++ {
+		s, err := random.Sample(items, 2)
+		require.Nil(t, err)
+		require.NotEqual(t, s[0], s[1])
+		counts[s[0]+s[1]]++
+	}
+	probs = map[string]float64{}
+	for _, a := range items {
+		for _, b := range items {
+			if a != b {
+				probs[a+b] = 1.0 / 20
+			}
+		}
+	}
+	requireUniform(t, counts, trials, probs)
+
+	all, err := random.Sample(items, 5)
+	require.Nil(t, err)
+	require.ElementsMatch(t, items, all)
+
+	_, err = random.Choice([]int{})
+	require.NotNil(t, err)
+	_, err = random.Sample(items, 6)
+	require.NotNil(t, err)
+
+	// a few indexes of a huge range don't allocate the range
+	huge, err := random.NewGenerator(crand.Reader).Sample(1<<30, 3)
+	require.Nil(t, err)
+	require.Len(t, huge, 3)
+	require.NotEqual(t, huge[0], huge[1])
+	require.NotEqual(t, huge[1], huge[2])
+	require.NotEqual(t, huge[0], huge[2])
+}
+
+func TestWith(t *testing.T) {
+	seed := make([]byte, random.DRBGSeedSize)
+	seeded := func() *random.Generator {
+		d, err := random.NewSeededDRBG(seed, []byte("with"))
+		require.Nil(t, err)
+		return random.NewGenerator(d)
+	}
+	items := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	// the same seed gives the same results
+	run := func() []string {
+		g := seeded()
+		s := append([]string{}, items...)
+		require.Nil(t, random.ShuffleWith(g, s))
+		c, err := random.ChoiceWith(g, items)
+		require.Nil(t, err)
+		sample, err := random.SampleWith(g, items, 3)
+		require.Nil(t, err)
+		w, err := random.WeightedChoiceWith(g, items, []float64{1, 1, 1, 1, 1, 1, 1, 1})
+		require.Nil(t, err)
+		return append(append(s, c, w), sample...)
+	}
+	first := run()
+	require.Equal(t, first, run())
+	require.ElementsMatch(t, items, first[:len(items)])
+}
+
+func TestWeightedChoice(t *testing.T) {
+	const trials = 100000
+	items := []string{"common", "rare", "never", "epic"}
+	weights := []float64{70, 25, 0, 5}
+
+	counts := map[string]int{}
+	for i := 0; i < trials; i++ {
+		c, err := random.WeightedChoice(items, weights)
+		require.Nil(t, err)
+		counts[c]++
+	}
+	requireUniform(t, counts, trials, map[string]float64{"common": 0.7, "rare": 0.25, "epic": 0.05})
+
+	_, err := random.WeightedChoice(items, []float64{1, 2})
+	require.NotNil(t, err)
+	_, err = random.WeightedChoice(items, []float64{0, 0, 0, 0})
+	require.NotNil(t, err)
+	_, err = random.WeightedChoice(items, []float64{1, -1, 1, 1})
+	require.NotNil(t, err)
+	_, err = random.WeightedChoice(items, []float64{1, math.NaN(), 1, 1})
+	require.NotNil(t, err)
+
+	f, err := random.NewGenerator(crand.Reader).Float64()
+	require.Nil(t, err)
+	require.True(t, f >= 0 && f < 1)
+}
+
+// entrantsOf returns n entrant names
+func entrantsOf(n int) []string {
+	entrants := make([]string, n)
+	for i := range entrants {
+		entrants[i] = fmt.Sprintf("player-%d", i)
+	}
+	return entrants
+}
+
+func TestDraw(t *testing.T) {
+	entrants := entrantsOf(100)
+	d, err := random.NewDraw(entrants)
+	require.Nil(t, err)
+	require.Len(t, d.Commitment, 128)
+
+	// the commitment and the entrants are published, then the public seed becomes known
+	transcript, err := d.Winners(3, "block 812345 hash 00000000000000000002a7c4", "alice")
+	require.Nil(t, err)
+	require.Len(t, transcript.Winners, 3)
+	require.Equal(t, d.Commitment, transcript.Commitment)
+	require.Nil(t, random.VerifyTranscript(transcript, entrants, nil))
+
+	_, err = d.Winners(3, "another seed")
+	require.NotNil(t, err)
+
+	// signed with rsa
+	priv, err := rsa.LoadPrivateKey("../cnigma/testdata/rsa-private-pkcs8.key")
+	require.Nil(t, err)
+	r, err := rsa.NewRSA(types.Base64)
+	require.Nil(t, err)
+	r.PrivateKey = priv
+	r.PublicKey = &priv.PublicKey
+
+	require.Nil(t, transcript.Sign(r))
+	require.Nil(t, random.VerifyTranscript(transcript, entrants, r))
+
+	buf, err := json.Marshal(transcript)
+	require.Nil(t, err)
+	var decoded random.Transcript
+	require.Nil(t, json.Unmarshal(buf, &decoded))
+	require.Nil(t, random.VerifyTranscript(decoded, entrants, r))
+
+	// tampering is detected
+	tampered := decoded
+	tampered.Winners = append([]int{}, decoded.Winners...)
+	tampered.Winners[0] = (tampered.Winners[0] + 1) % 100
+	require.NotNil(t, random.VerifyTranscript(tampered, entrants, nil))
+
+	tampered = decoded
+	tampered.ClientSeeds = []string{"block 812345 hash 00000000000000000002a7c4", "bob"}
+	require.NotNil(t, random.VerifyTranscript(tampered, entrants, nil))
+
+	tampered = decoded
+	tampered.ServerSeed = strings.Repeat("00", 32)
+	require.NotNil(t, random.VerifyTranscript(tampered, entrants, nil))
+
+	unsigned := decoded
+	unsigned.Signature = ""
+	require.NotNil(t, random.VerifyTranscript(unsigned, entrants, r))
+
+	// a reordered list of entrants fails, even with its own hash in the transcript
+	reordered := append([]string{}, entrants...)
+	reordered[0], reordered[1] = reordered[1], reordered[0]
+	require.NotNil(t, random.VerifyTranscript(decoded, reordered, nil))
+	other, err := random.NewDraw(reordered)
+	require.Nil(t, err)
+	tampered = decoded
+	tampered.EntrantsHash = other.Commitment[64:]
+	require.NotNil(t, random.VerifyTranscript(tampered, reordered, nil))
+	require.NotNil(t, random.VerifyTranscript(decoded, entrants[:99], nil))
+
+	// the seeds determine the winners, and the seed boundaries matter
+	other, err = random.NewDraw(entrantsOf(1000))
+	require.Nil(t, err)
+	a, err := other.Winners(5, "ab", "c")
+	require.Nil(t, err)
+	a.ClientSeeds = []string{"a", "bc"}
+	require.NotNil(t, random.VerifyTranscript(a, entrantsOf(1000), nil))
+
+	d, err = random.NewDraw(entrantsOf(3))
+	require.Nil(t, err)
+	_, err = d.Winners(4, "seed")
+	require.NotNil(t, err)
+	_, err = d.Winners(2)
+	require.NotNil(t, err)
+	_, err = random.NewDraw(nil)
+	require.NotNil(t, err)
+
+	// oversized transcripts are rejected without allocating
+	huge := decoded
+	huge.Entrants = math.MaxInt
+	require.NotNil(t, random.VerifyTranscript(huge, entrants, nil))
+	huge = decoded
+	huge.Winners = make([]int, random.MaxDrawWinners+1)
+	require.NotNil(t, random.VerifyTranscript(huge, entrants, nil))
+}
+
+// the outcome of cnigma-draw-v2 must never change, or published transcripts would fail verification
+func TestDrawKnownAnswer(t *testing.T) {
+	seed := make([]byte, 32)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	clientSeeds := []string{"block 812345 hash 00000000000000000002a7c4", "alice"}
+
+	for n, expected := range map[int]struct {
+		entrantsHash string
+		winners      []int
+	}{
+		7:      {"2f583fa5a9ff6dcba5a77f54a3736b3d16fc878fafe3aa19943ab2a7778e0991", []int{1, 6, 0, 2, 3}},
+		1000:   {"cd4ec307f796efce928b6ac69ea2cf405bc868c79177577e75f6617de51b7871", []int{547, 792, 378, 773, 5}},
+		100000: {"fee6f9e0d53ccffba06507a3df544b776c53f5c4bac6421babd6bfee4f9ebebd", []int{9940, 42891, 95751, 40929, 25520}},
+	} {
+		transcript := random.Transcript{
+			Scheme:       random.DrawScheme,
+			Commitment:   "630dcd2966c4336691125448bbb25b4ff412a49c732db2c8abc1b8581bd710dd" + expected.entrantsHash,
+			ServerSeed:   hex.EncodeToString(seed),
+			ClientSeeds:  clientSeeds,
+			Entrants:     n,
+			EntrantsHash: expected.entrantsHash,
+			Winners:      expected.winners,
+		}
+		require.Nil(t, random.VerifyTranscript(transcript, entrantsOf(n), nil), n)
+	}
 }
@@ -0,0 +1,284 @@
+// Package randtest runs statistical tests in the style of NIST SP 800-22 on random data.
+//
+// The tests can only reveal obviously broken generators, passing them doesn't prove
+// that the data is unpredictable.
+package randtest
+
+import (
+	"errors"
+	"math"
+)
+
+// Alpha is the default significance level, results with a p-value below it fail
+const Alpha = 0.01
+
+// errTooShort is returned when the data is too short for a test
+var errTooShort = errors.New("not enough data for the test")
+
+// Result is the outcome of one test
+type Result struct {
+	Name      string
+	Statistic float64 // the test statistic, or the estimate of estimators
+	PValue    float64 // NaN for estimators which have no p-value
+	Pass      bool
+	Err       error // the test couldn't run, e.g. not enough data
+}
+
+// Bits is a bit sequence, one bit per element
+type Bits []uint8
+
+// FromBytes returns the bits of data, most significant bit first
+func FromBytes(data []byte) Bits {
+	bits := make(Bits, 0, len(data)*8)
+	for _, b := range data {
+		for i := 7; i >= 0; i-- {
+			bits = append(bits, b>>i&1)
+		}
+	}
+	return bits
+}
+
+// ParseBits parses a string of '0' and '1', other characters are ignored
+func ParseBits(s string) Bits {
+	var bits Bits
+	for _, c := range s {
+		if c == '0' || c == '1' {
+			bits = append(bits, uint8(c-'0'))
+		}
+	}
+	return bits
+}
+
+// Run runs every test on data with the significance level alpha
+func Run(data []byte, alpha float64) []Result {
+	bits := FromBytes(data)
+	results := []Result{
+		Frequency(bits),
+		BlockFrequency(bits, 128),
+		Runs(bits),
+		LongestRun(bits),
+		ChiSquare(data),
+		SerialCorrelation(data),
+		Entropy(data),
+		MinEntropy(data),
+	}
+	for i := range results {
+		r := &results[i]
+		if r.Err == nil && !math.IsNaN(r.PValue) {
+			r.Pass = r.PValue >= alpha
+		}
+	}
+	return results
+}
+
+// result returns a Result which passes at the default significance level
+func result(name string, statistic, p float64) Result {
+	return Result{Name: name, Statistic: statistic, PValue: p, Pass: p >= Alpha}
+}
+
+// Frequency is the frequency (monobit) test, SP 800-22 2.1.
+// At least 100 bits are recommended for this and the runs test.
+func Frequency(bits Bits) Result {
+	const name = "frequency"
+	n := len(bits)
+	if n < 2 {
+		return Result{Name: name, Err: errTooShort}
+	}
+	s := 0
+	for _, b := range bits {
+		s += 2*int(b) - 1
+	}
+	obs := math.Abs(float64(s)) / math.Sqrt(float64(n))
+	return result(name, obs, math.Erfc(obs/math.Sqrt2))
+}
+
+// BlockFrequency is the frequency test within blocks of m bits, SP 800-22 2.2
+func BlockFrequency(bits Bits, m int) Result {
+	const name = "block frequency"
+	n := len(bits)
+	blocks := n / m
+	if m < 2 || blocks < 1 {
+		return Result{Name: name, Err: errTooShort}
+	}
+	chi := 0.0
+	for i := 0; i < blocks; i++ {
+		ones := 0
+		for _, b := range bits[i*m : (i+1)*m] {
+			ones += int(b)
+		}
+		pi := float64(ones)/float64(m) - 0.5
+		chi += pi * pi
+	}
+	chi *= 4 * float64(m)
+	return result(name, chi, igamc(float64(blocks)/2, chi/2))
+}
+
+// Runs is the runs test, SP 800-22 2.3
+func Runs(bits Bits) Result {
+	const name = "runs"
+	if len(bits) < 2 {
+		return Result{Name: name, Err: errTooShort}
+	}
+	n := float64(len(bits))
+	ones := 0
+	for _, b := range bits {
+		ones += int(b)
+	}
+	pi := float64(ones) / n
+
+	// the frequency test is a prerequisite
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(n) {
+		return result(name, 0, 0)
+	}
+
+	v := 1
+	for i := 1; i < len(bits); i++ {
+		if bits[i] != bits[i-1] {
+			v++
+		}
+	}
+	p := math.Erfc(math.Abs(float64(v)-2*n*pi*(1-pi)) / (2 * math.Sqrt(2*n) * pi * (1 - pi)))
+	return result(name, float64(v), p)
+}
+
+// LongestRun is the test for the longest run of ones in a block, SP 800-22 2.4
+func LongestRun(bits Bits) Result {
+	const name = "longest run"
+	n := len(bits)
+
+	var m int
+	var classes []int // upper bounds of the classes, the last class is unbounded
+	var probs []float64
+	switch {
+	case n >= 750000:
+		m = 10000
+		classes = []int{10, 11, 12, 13, 14, 15}
+		probs = []float64{0.0882, 0.2092, 0.2483, 0.1933, 0.1208, 0.0675, 0.0727}
+	case n >= 6272:
+		m = 128
+		classes = []int{4, 5, 6, 7, 8}
+		probs = []float64{0.1174035788, 0.242955959, 0.249363483, 0.17517706, 0.102701071, 0.112398847}
+	case n >= 128:
+		m = 8
+		classes = []int{1, 2, 3}
+		probs = []float64{0.21484375, 0.3671875, 0.23046875, 0.1875}
+	default:
+		return Result{Name: name, Err: errTooShort}
+	}
+
+	blocks := n / m
+	counts := make([]int, len(probs))
+	for i := 0; i < blocks; i++ {
+		longest, run := 0, 0
+		for _, b := range bits[i*m : (i+1)*m] {
+			if b == 1 {
+				run++
+				if run > longest {
+					longest = run
+				}
+			} else {
+				run = 0
+			}
+		}
+		k := 0
+		for k < len(classes) && longest > classes[k] {
+			k++
+		}
+		counts[k]++
+	}
+
+	chi := 0.0
+	for i, p := range probs {
+		expected := float64(blocks) * p
+		d := float64(counts[i]) - expected
+		chi += d * d / expected
+	}
+	return result(name, chi, igamc(float64(len(probs)-1)/2, chi/2))
+}
+
+// ChiSquare tests whether all 256 byte values are equally frequent
+func ChiSquare(data []byte) Result {
+	const name = "chi-square"
+	n := len(data)
+	// at least 5 expected occurrences per value
+	if n < 5*256 {
+		return Result{Name: name, Err: errTooShort}
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	expected := float64(n) / 256
+	chi := 0.0
+	for _, c := range counts {
+		d := float64(c) - expected
+		chi += d * d / expected
+	}
+	return result(name, chi, igamc(255.0/2, chi/2))
+}
+
+// SerialCorrelation tests the correlation of each byte with the next one,
+// the coefficient is close to zero for random data.
+func SerialCorrelation(data []byte) Result {
+	const name = "serial correlation"
+	n := len(data)
+	if n < 100 {
+		return Result{Name: name, Err: errTooShort}
+	}
+	var sum, sumSq, sumNext float64
+	for i, b := range data {
+		x := float64(b)
+		sum += x
+		sumSq += x * x
+		sumNext += x * float64(data[(i+1)%n])
+	}
+	fn := float64(n)
+	denominator := fn*sumSq - sum*sum
+	if denominator == 0 {
+		return result(name, 1, 0)
+	}
+	r := (fn*sumNext - sum*sum) / denominator
+	z := math.Abs(r) * math.Sqrt(fn)
+	return result(name, r, math.Erfc(z/math.Sqrt2))
+}
+
+// Entropy estimates the shannon entropy in bits per byte, it has no p-value
+func Entropy(data []byte) Result {
+	const name = "entropy (bits/byte)"
+	if len(data) == 0 {
+		return Result{Name: name, Err: errTooShort}
+	}
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	h := 0.0
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / float64(len(data))
+			h -= p * math.Log2(p)
+		}
+	}
+	return Result{Name: name, Statistic: h, PValue: math.NaN(), Pass: true}
+}
+
+// MinEntropy is the most common value estimate of SP 800-90B 6.3.1 in bits per byte,
+// it has no p-value.
+func MinEntropy(data []byte) Result {
+	const name = "min-entropy (bits/byte)"
+	n := len(data)
+	if n < 2 {
+		return Result{Name: name, Err: errTooShort}
+	}
+	var counts [256]int
+	max := 0
+	for _, b := range data {
+		counts[b]++
+		if counts[b] > max {
+			max = counts[b]
+		}
+	}
+	p := float64(max) / float64(n)
+	upper := math.Min(1, p+2.576*math.Sqrt(p*(1-p)/float64(n-1)))
+	return Result{Name: name, Statistic: -math.Log2(upper), PValue: math.NaN(), Pass: true}
+}
@@ -0,0 +1,73 @@
+package randtest_test
+
+import (
+	"crypto/rand"
+	"math"
+	"testing"
+
+	"github.com/keng42/go/random/randtest"
+	"github.com/stretchr/testify/require"
+)
+
+// the 100 bits example of NIST SP 800-22
+const epsilon100 = "1100100100001111110110101010001000100001011010001100001000110100110001001100011001100010100010111000"
+
+func TestNISTExamples(t *testing.T) {
+	r := randtest.Frequency(randtest.ParseBits("1011010101"))
+	require.InDelta(t, 0.527089, r.PValue, 1e-6)
+
+	r = randtest.Frequency(randtest.ParseBits(epsilon100))
+	require.InDelta(t, 0.109599, r.PValue, 1e-6)
+	require.True(t, r.Pass)
+
+	r = randtest.BlockFrequency(randtest.ParseBits("0110011010"), 3)
+	require.InDelta(t, 0.801252, r.PValue, 1e-6)
+
+	r = randtest.BlockFrequency(randtest.ParseBits(epsilon100), 10)
+	require.InDelta(t, 0.706438, r.PValue, 1e-6)
+
+	r = randtest.Runs(randtest.ParseBits("1001101011"))
+	require.InDelta(t, 0.147232, r.PValue, 1e-6)
+
+	r = randtest.Runs(randtest.ParseBits(epsilon100))
+	require.InDelta(t, 0.500798, r.PValue, 1e-6)
+
+	r = randtest.LongestRun(randtest.ParseBits(
+		"11001100000101010110110001001100111000000000001001001101010100010001001111010110100000001101011111001100111001101101100010110010"))
+	require.InDelta(t, 0.180609, r.PValue, 1e-4)
+}
+
+func TestRun(t *testing.T) {
+	data := make([]byte, 1<<17)
+	_, err := rand.Read(data)
+	require.Nil(t, err)
+
+	results := randtest.Run(data, 1e-6)
+	require.Len(t, results, 8)
+	for _, r := range results {
+		require.Nil(t, r.Err, r.Name)
+		require.True(t, r.Pass, r.Name)
+	}
+	require.InDelta(t, 8, results[6].Statistic, 0.01)
+	require.Greater(t, results[7].Statistic, 7.5)
+	require.True(t, math.IsNaN(results[7].PValue))
+
+	// a counter fails most tests
+	for i := range data {
+		data[i] = byte(i)
+	}
+	failed := 0
+	for _, r := range randtest.Run(data, randtest.Alpha) {
+		if !r.Pass {
+			failed++
+		}
+	}
+	require.GreaterOrEqual(t, failed, 2)
+
+	// too short for most tests
+	for _, r := range randtest.Run(data[:4], randtest.Alpha) {
+		if r.Err != nil {
+			require.False(t, r.Pass)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package random
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Float64 returns a uniform random float64 in [0, 1) with 53 bits of precision
+func (g *Generator) Float64() (float64, error) {
+	b, err := g.Bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return float64(binary.BigEndian.Uint64(b)>>11) / (1 << 53), nil
+}
+
+// Perm returns a uniform random permutation of [0, n)
+func (g *Generator) Perm(n int) ([]int, error) {
+	if n < 0 {
+		return nil, errors.New("invalid argument to Perm")
+	}
+	p := make([]int, n)
+	for i := range p {
+		p[i] = i
+	}
+	err := g.Shuffle(n, func(i, j int) { p[i], p[j] = p[j], p[i] })
+	return p, err
+}
+
+// Shuffle shuffles n elements with the Fisher-Yates algorithm, swap swaps the elements i and j
+func (g *Generator) Shuffle(n int, swap func(i, j int)) error {
+	if n < 0 {
+		return errors.New("invalid argument to Shuffle")
+	}
+	for i := n - 1; i > 0; i-- {
+		j, err := g.Intn(i + 1)
+		if err != nil {
+			return err
+		}
+		swap(i, j)
+	}
+	return nil
+}
+
+// Sample returns k distinct indexes of [0, n) in random order
+func (g *Generator) Sample(n, k int) ([]int, error) {
+	if k < 0 || k > n {
+		return nil, errors.New("sample size must be between 0 and n")
+	}
+	// a partial Fisher-Yates shuffle of the first k positions,
+	// only the moved positions are stored so it needs O(k) memory whatever n is
+	moved := map[int]int{}
+	at := func(i int) int {
+		if v, ok := moved[i]; ok {
+			return v
+		}
+		return i
+	}
+	out := make([]int, k)
+	for i := 0; i < k; i++ {
+		j, err := g.Intn(n - i)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = at(i + j)
+		moved[i+j] = at(i)
+	}
+	return out, nil
+}
+
+// WeightedIndex returns an index with probability proportional to its weight,
+// weights must not be negative and at least one must be positive.
+func (g *Generator) WeightedIndex(weights []float64) (int, error) {
+	total := 0.0
+	for _, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return 0, errors.New("weights must be finite and not negative")
+		}
+		total += w
+	}
+	if total <= 0 || math.IsInf(total, 0) {
+		return 0, errors.New("weights must have a positive finite sum")
+	}
+
+	f, err := g.Float64()
+	if err != nil {
+		return 0, err
+	}
+	r := f * total
+	last := 0
+	for i, w := range weights {
+		if w == 0 {
+			continue
+		}
+		if r < w {
+			return i, nil
+		}
+		r -= w
+		last = i
+	}
+	// rounding errors can leave r slightly above the last weight
+	return last, nil
+}
+
+// Perm returns a uniform random permutation of [0, n)
+func Perm(n int) ([]int, error) {
+	return defaultGenerator.Perm(n)
+}
+
+// Shuffle shuffles s in place, see ShuffleWith for another source
+func Shuffle[T any](s []T) error {
+	return ShuffleWith(defaultGenerator, s)
+}
+
+// ShuffleWith shuffles s in place using g
+func ShuffleWith[T any](g *Generator, s []T) error {
+	return g.Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
+}
+
+// Choice returns a uniformly chosen element of s, see ChoiceWith for another source
+func Choice[T any](s []T) (T, error) {
+	return ChoiceWith(defaultGenerator, s)
+}
+
+// ChoiceWith returns a uniformly chosen element of s using g
+func ChoiceWith[T any](g *Generator, s []T) (T, error) {
+	var zero T
+	if len(s) == 0 {
+		return zero, errors.New("choice from an empty slice")
+	}
+	i, err := g.Intn(len(s))
+	if err != nil {
+		return zero, err
+	}
+	return s[i], nil
+}
+
+// Sample returns k distinct elements of s in random order, see SampleWith for another source
+func Sample[T any](s []T, k int) ([]T, error) {
+	return SampleWith(defaultGenerator, s, k)
+}
+
+// SampleWith returns k distinct elements of s in random order using g
+func SampleWith[T any](g *Generator, s []T, k int) ([]T, error) {
+	indexes, err := g.Sample(len(s), k)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]T, k)
+	for i, index := range indexes {
+		out[i] = s[index]
+	}
+	return out, nil
+}
+
+// WeightedChoice returns an element of s with probability proportional to its weight,
+// see WeightedChoiceWith for another source.
+func WeightedChoice[T any](s []T, weights []float64) (T, error) {
+	return WeightedChoiceWith(defaultGenerator, s, weights)
+}
+
+// WeightedChoiceWith returns an element of s with probability proportional to its weight using g
+func WeightedChoiceWith[T any](g *Generator, s []T, weights []float64) (T, error) {
+	var zero T
+	if len(s) != len(weights) {
+		return zero, errors.New("every element requires a weight")
+	}
+	i, err := g.WeightedIndex(weights)
+	if err != nil {
+		return zero, err
+	}
+	return s[i], nil
+}
@@ -0,0 +1,58 @@
+package token_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keng42/go/random/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken(t *testing.T) {
+	tok, err := token.Generate("myapp")
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(tok, "myapp_"))
+	require.Len(t, tok, len("myapp_")+token.EntropyLength+token.ChecksumLength)
+
+	require.True(t, token.Validate(tok, "myapp"))
+	require.False(t, token.Validate(tok, "other"))
+
+	parsed, err := token.Parse(tok)
+	require.Nil(t, err)
+	require.Equal(t, "myapp", parsed.Prefix)
+	require.Len(t, parsed.Entropy, token.EntropyLength)
+	require.Equal(t, tok, parsed.String())
+
+	// every single character typo is caught
+	for i := range tok {
+		for _, c := range "0aZ" {
+			if tok[i] == byte(c) || tok[i] == '_' {
+				continue
+			}
+			typo := tok[:i] + string(c) + tok[i+1:]
+			require.False(t, token.Validate(typo, "myapp"), typo)
+		}
+	}
+
+	other, err := token.Generate("myapp")
+	require.Nil(t, err)
+	require.NotEqual(t, tok, other)
+
+	// prefixes with underscores
+	tok, err = token.Generate("my_app_live")
+	require.Nil(t, err)
+	require.True(t, token.Validate(tok, "my_app_live"))
+
+	require.Len(t, token.Hash(tok), 64)
+	require.NotEqual(t, token.Hash(tok), token.Hash(other))
+	require.Equal(t, "my_app_live_..."+tok[len(tok)-4:], token.Hint(tok))
+
+	for _, prefix := range []string{"", "MyApp", "my-app", strings.Repeat("a", 33)} {
+		_, err = token.Generate(prefix)
+		require.NotNil(t, err, prefix)
+	}
+	for _, s := range []string{"", "myapp", "myapp_short", "myapp_" + strings.Repeat("-", 36)} {
+		_, err = token.Parse(s)
+		require.NotNil(t, err, s)
+	}
+}